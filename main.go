@@ -1,17 +1,24 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
 	"io"
 	"log"
+	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/wenwu449/vsts-pr/vsts"
 )
 
 const (
 	logPath = "LOG_PATH"
+
+	defaultListenAddress = ":8080"
+	shutdownTimeout      = 30 * time.Second
 )
 
 func main() {
@@ -34,20 +41,110 @@ func main() {
 		log.Fatal(err)
 	}
 
-	pr, err := vsts.ParsePullRequest()
+	dryRun := flag.Bool("dry-run", config.DryRun, "record comments, thread status changes and votes instead of sending them")
+	dryRunOutput := flag.String("dry-run-output", config.DryRunOutput, "file to write recorded dry-run calls to as JSON")
+	flag.Parse()
+
+	client := vsts.NewClient(config, nil)
+
+	var recorder *vsts.Recorder
+	if *dryRun {
+		recorder = client.EnableDryRun()
+	}
+
+	if flag.Arg(0) == "serve" {
+		serve(client, flag.Args()[1:])
+	} else {
+		err = reviewOnce(client)
+	}
+
+	if recorder != nil {
+		writeDryRunOutput(recorder, *dryRunOutput)
+	}
+
 	if err != nil {
 		log.Fatal(err)
 	}
+}
 
-	log.Printf("Got PR update: %v\n", pr.Resource.PullRequestID)
+func writeDryRunOutput(recorder *vsts.Recorder, path string) {
+	if len(path) == 0 {
+		return
+	}
 
-	if !strings.EqualFold(pr.Resource.TargetRefName, fmt.Sprintf("%s/%s", "refs/heads", config.MasterBranch)) {
-		fmt.Printf("unexpected target branch: %s\n", pr.Resource.TargetRefName)
+	file, err := os.Create(path)
+	if err != nil {
+		log.Printf("failed to write dry-run output: %v\n", err)
 		return
 	}
+	defer file.Close()
+
+	if err := recorder.WriteJSON(file); err != nil {
+		log.Printf("failed to write dry-run output: %v\n", err)
+	}
+}
 
-	err = vsts.Review(pr)
+// reviewOnce reviews the pull request passed in env PR_CONTENT
+func reviewOnce(client *vsts.Client) error {
+	pr, err := vsts.ParsePullRequest()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	log.Printf("Got PR update: %v\n", pr.Resource.PullRequestID)
+
+	if !pr.IsTargetBranch(client.Config().MasterBranch) {
+		log.Printf("unexpected target branch: %s\n", pr.Resource.TargetRefName)
+		return nil
+	}
+
+	return client.Review(pr)
+}
+
+// serve reviews pull requests posted by service hooks until interrupted
+func serve(client *vsts.Client, args []string) {
+	listenAddress := client.Config().ListenAddress
+	if len(listenAddress) == 0 {
+		listenAddress = defaultListenAddress
+	}
+
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", listenAddress, "listen address of the webhook server")
+	flags.Parse(args)
+
+	handler := vsts.NewWebhookHandler(client)
+
+	mux := http.NewServeMux()
+	mux.Handle("/webhook", handler)
+	mux.HandleFunc("/healthcheck", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: mux,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		log.Printf("Listening on %s\n", *addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-stop
+	log.Println("Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("shutdown: %v\n", err)
+	}
+
+	// let accepted reviews finish
+	handler.Wait()
+	log.Println("Server stopped.")
 }
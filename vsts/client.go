@@ -1,59 +1,115 @@
 package vsts
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 )
 
-func getFromVsts(url string, v interface{}) error {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
+// Client accesses VSTS APIs of the repository in its configuration
+type Client struct {
+	config     *Config
+	httpClient *http.Client
+	recorder   *Recorder
+	throttle   throttle
+	hunks      hunkCache
+}
+
+// NewClient creates a client, http.DefaultClient is used if httpClient is nil
+func NewClient(config *Config, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
 	}
 
-	req.SetBasicAuth(config.Username, config.Password)
-	resp, err := client.Do(req)
+	return &Client{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+// Config returns the configuration of the client
+func (c *Client) Config() *Config {
+	return c.config
+}
+
+func (c *Client) getFromVsts(url string, v interface{}) error {
+	resp, err := c.do("GET", url, nil, "")
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("repsonse with non 200 code of %d", resp.StatusCode)
+		return &statusError{resp.StatusCode}
 	}
 
 	return json.NewDecoder(resp.Body).Decode(v)
 }
 
-func postToVsts(url string, v interface{}) error {
-	return sendToVsts("POST", url, v)
+func (c *Client) getRawFromVsts(url string) ([]byte, error) {
+	resp, err := c.do("GET", url, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, &statusError{resp.StatusCode}
+	}
+
+	return ioutil.ReadAll(resp.Body)
 }
 
-func putToVsts(url string, v interface{}) error {
-	return sendToVsts("PUT", url, v)
+// statusError is returned for unexpected response status codes
+type statusError struct {
+	StatusCode int
 }
 
-func patchToVsts(url string, v interface{}) error {
-	return sendToVsts("PATCH", url, v)
+func (e *statusError) Error() string {
+	return fmt.Sprintf("repsonse with non 200 code of %d", e.StatusCode)
 }
 
-func sendToVsts(method string, url string, v interface{}) error {
-	client := &http.Client{}
-	body := new(bytes.Buffer)
-	json.NewEncoder(body).Encode(v)
-	req, err := http.NewRequest(method, url, body)
+func isNotFound(err error) bool {
+	e, ok := err.(*statusError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+func (c *Client) postToVsts(url string, v interface{}) error {
+	return c.sendToVsts("POST", url, v, nil)
+}
+
+// postToVstsWithResult decodes the created resource into result, which is left untouched in dry-run mode
+func (c *Client) postToVstsWithResult(url string, v interface{}, result interface{}) error {
+	return c.sendToVsts("POST", url, v, result)
+}
+
+func (c *Client) putToVsts(url string, v interface{}) error {
+	return c.sendToVsts("PUT", url, v, nil)
+}
+
+func (c *Client) patchToVsts(url string, v interface{}) error {
+	return c.sendToVsts("PATCH", url, v, nil)
+}
+
+func (c *Client) sendToVsts(method string, url string, v interface{}, result interface{}) error {
+	if c.recorder != nil {
+		c.recorder.record(method, url, v)
+		return nil
+	}
+
+	body, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
 
-	req.SetBasicAuth(config.Username, config.Password)
-	req.Header.Set("Content-Type", "application/json")
+	contentType := "application/json"
+	if _, ok := v.(jsonPatch); ok {
+		contentType = "application/json-patch+json"
+	}
 
-	resp, err := client.Do(req)
+	resp, err := c.do(method, url, body, contentType)
 	if err != nil {
 		return err
 	}
@@ -64,5 +120,9 @@ func sendToVsts(method string, url string, v interface{}) error {
 		return fmt.Errorf("repsonse with non 200|201 code of %d", resp.StatusCode)
 	}
 
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+
 	return nil
 }
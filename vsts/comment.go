@@ -2,54 +2,70 @@ package vsts
 
 import (
 	"log"
+	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 )
 
-func getThreadsURL(pullRequestID int) string {
-	threadsURLTemplate := "https://{instance}/DefaultCollection/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/threads?api-version={version}"
+func (c *Client) getThreadsURL(pullRequestID int) string {
+	threadsURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/threads?api-version={version}"
 
 	r := strings.NewReplacer(
-		"{instance}", config.Instance,
-		"{project}", config.Project,
-		"{repository}", config.Repo,
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
 		"{pullRequest}", strconv.Itoa(pullRequestID),
-		"{version}", "3.0-preview")
+		"{version}", c.getAPIVersion(apiThreads))
 
 	return r.Replace(threadsURLTemplate)
 }
 
-func getThreadURL(pullRequestID int, threadID int) string {
-	threadURLTempate := "https://{instance}/DefaultCollection/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/threads/{threadID}?api-version={version}"
+func (c *Client) getThreadURL(pullRequestID int, threadID int) string {
+	threadURLTempate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/threads/{threadID}?api-version={version}"
 	r := strings.NewReplacer(
-		"{instance}", config.Instance,
-		"{project}", config.Project,
-		"{repository}", config.Repo,
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
 		"{pullRequest}", strconv.Itoa(pullRequestID),
 		"{threadID}", strconv.Itoa(threadID),
-		"{version}", "3.0-preview")
+		"{version}", c.getAPIVersion(apiThreads))
 
 	return r.Replace(threadURLTempate)
 }
 
-func getCommentURL(pullRequestID int, threadID int) string {
-	commentURLTempate := "https://{instance}/DefaultCollection/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/threads/{threadID}/comments?api-version={version}"
+func (c *Client) getCommentURL(pullRequestID int, threadID int) string {
+	commentURLTempate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/threads/{threadID}/comments?api-version={version}"
 	r := strings.NewReplacer(
-		"{instance}", config.Instance,
-		"{project}", config.Project,
-		"{repository}", config.Repo,
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
 		"{pullRequest}", strconv.Itoa(pullRequestID),
 		"{threadID}", strconv.Itoa(threadID),
-		"{version}", "3.0-preview")
+		"{version}", c.getAPIVersion(apiComments))
 
 	return r.Replace(commentURLTempate)
 }
 
-func getCommentThreads(pullRequestID int) (*commentThreads, error) {
+func (c *Client) getCommentItemURL(pullRequestID int, threadID int, commentID int) string {
+	commentURLTempate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/threads/{threadID}/comments/{commentID}?api-version={version}"
+	r := strings.NewReplacer(
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
+		"{pullRequest}", strconv.Itoa(pullRequestID),
+		"{threadID}", strconv.Itoa(threadID),
+		"{commentID}", strconv.Itoa(commentID),
+		"{version}", c.getAPIVersion(apiComments))
+
+	return r.Replace(commentURLTempate)
+}
+
+func (c *Client) getCommentThreads(pullRequestID int) (*commentThreads, error) {
 	commentThreads := new(commentThreads)
 
-	url := getThreadsURL(pullRequestID)
-	err := getFromVsts(url, commentThreads)
+	url := c.getThreadsURL(pullRequestID)
+	err := c.getFromVsts(url, commentThreads)
 
 	if err != nil {
 		return nil, err
@@ -58,9 +74,47 @@ func getCommentThreads(pullRequestID int) (*commentThreads, error) {
 	return commentThreads, nil
 }
 
-func createCommentThread(pullRequestID int, filePath string, status int, content string) error {
+// getFileThreadContext attaches a thread to the beginning of a file, or nowhere when filePath is empty
+func getFileThreadContext(filePath string) threadContext {
+	if filePath == "" {
+		return threadContext{}
+	}
+
+	return threadContext{
+		FilePath: filePath,
+		RightFileStart: filePosition{
+			Line:   1,
+			Offset: 1,
+		},
+		RightFileEnd: filePosition{
+			Line:   1,
+			Offset: 3,
+		},
+	}
+}
+
+// markdownProperty is the thread property rendering comments as markdown
+const markdownProperty = "Microsoft.TeamFoundation.Discussion.SupportsMarkdown"
+
+// createCommentThread creates a thread, the returned thread has ID 0 in dry-run mode
+func (c *Client) createCommentThread(pullRequestID int, context threadContext, status int, content string) (*commentThread, error) {
+	return c.createCommentThreadWithProperties(pullRequestID, context, status, content, nil)
+}
+
+// createCommentThreadWithProperties creates a thread with additional properties
+func (c *Client) createCommentThreadWithProperties(pullRequestID int, context threadContext, status int, content string, properties threadProperties) (*commentThread, error) {
 	log.Printf("Creating comment thread to PR %v...\n", pullRequestID)
 
+	allProperties := threadProperties{
+		markdownProperty: {
+			Type:  "System.Int32",
+			Value: 1,
+		},
+	}
+	for name, property := range properties {
+		allProperties[name] = property
+	}
+
 	thread := postThread{
 		Comments: []postComment{
 			{
@@ -69,41 +123,25 @@ func createCommentThread(pullRequestID int, filePath string, status int, content
 				CommentType:     1,
 			},
 		},
-		Properties: threadProperty{
-			MicrosoftTeamFoundationDiscussionSupportsMarkdown: supportsMarkDown{
-				Type:  "System.Int32",
-				Value: 1,
-			},
-		},
-		Status: status,
-		ThreadContext: threadContext{
-			FilePath: filePath,
-			RightFileStart: filePosition{
-				Line:   1,
-				Offset: 1,
-			},
-			RightFileEnd: filePosition{
-				Line:   1,
-				Offset: 3,
-			},
-		},
-	}
-
-	if filePath == "" {
-		thread.ThreadContext = threadContext{}
+		Properties:    allProperties,
+		Status:        status,
+		ThreadContext: context,
 	}
 
-	url := getThreadsURL(pullRequestID)
+	url := c.getThreadsURL(pullRequestID)
 
-	err := postToVsts(url, thread)
+	createdThread := new(commentThread)
+	err := c.postToVstsWithResult(url, thread, createdThread)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return createdThread, nil
 }
 
-func addComment(pullRequestID int, thread commentThread, essentialMessage string, content string) error {
+// addComment replies to the last comment of a thread unless it contains essentialMessage,
+// the returned comment is nil if there was no reply and has ID 0 in dry-run mode
+func (c *Client) addComment(pullRequestID int, thread commentThread, essentialMessage string, content string) (*comment, error) {
 	lastCommentID := 0
 	commentContent := ""
 	for _, comment := range thread.Comments {
@@ -115,20 +153,38 @@ func addComment(pullRequestID int, thread commentThread, essentialMessage string
 
 	if strings.Contains(commentContent, essentialMessage) {
 		log.Printf("Already commented to PR %v thread %v...\n", pullRequestID, thread.ID)
-		return nil
+		return nil, nil
 	}
 
 	log.Printf("Adding comment to PR %v thread %v...\n", pullRequestID, thread.ID)
 
-	comment := postComment{
+	reply := postComment{
 		ParentCommentID: lastCommentID,
 		Content:         content,
 		CommentType:     1,
 	}
 
-	url := getCommentURL(pullRequestID, thread.ID)
+	url := c.getCommentURL(pullRequestID, thread.ID)
 
-	err := postToVsts(url, comment)
+	createdComment := new(comment)
+	err := c.postToVstsWithResult(url, reply, createdComment)
+	if err != nil {
+		return nil, err
+	}
+
+	return createdComment, nil
+}
+
+func (c *Client) updateComment(pullRequestID int, threadID int, commentID int, content string) error {
+	log.Printf("Updating comment %v of PR %v thread %v...\n", commentID, pullRequestID, threadID)
+
+	comment := patchComment{
+		Content: content,
+	}
+
+	url := c.getCommentItemURL(pullRequestID, threadID, commentID)
+
+	err := c.patchToVsts(url, comment)
 	if err != nil {
 		return err
 	}
@@ -136,7 +192,7 @@ func addComment(pullRequestID int, thread commentThread, essentialMessage string
 	return nil
 }
 
-func setCommentThreadStatus(pullRequestID int, thread commentThread, status int) error {
+func (c *Client) setCommentThreadStatus(pullRequestID int, thread commentThread, status int) error {
 	statusString := "active"
 	if status == 2 {
 		statusString = "fixed"
@@ -152,9 +208,38 @@ func setCommentThreadStatus(pullRequestID int, thread commentThread, status int)
 		Status: status,
 	}
 
-	url := getThreadURL(pullRequestID, thread.ID)
+	url := c.getThreadURL(pullRequestID, thread.ID)
+
+	err := c.patchToVsts(url, patchThread)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setCommentThreadProperties sets properties of a thread which differ from the given values
+func (c *Client) setCommentThreadProperties(pullRequestID int, thread commentThread, properties threadProperties) error {
+	changed := threadProperties{}
+	for name, property := range properties {
+		if current, ok := thread.Properties[name]; !ok || !reflect.DeepEqual(current.Value, property.Value) {
+			changed[name] = property
+		}
+	}
+	if len(changed) == 0 {
+		log.Printf("PR %v thread %v properties are up to date\n", pullRequestID, thread.ID)
+		return nil
+	}
+
+	log.Printf("Set PR %v thread %v properties...\n", pullRequestID, thread.ID)
+
+	patchThread := patchThreadProperties{
+		Properties: changed,
+	}
+
+	url := c.getThreadURL(pullRequestID, thread.ID)
 
-	err := patchToVsts(url, patchThread)
+	err := c.patchToVsts(url, patchThread)
 	if err != nil {
 		return err
 	}
@@ -16,41 +16,234 @@ type imageConfig struct {
 	Header     string `json:"header"`
 }
 
-type changeGroup []string
+// changeGroup is a rule for files updated together, either a list of files or an object
+type changeGroup struct {
+	// Files are globs of files updated together: once a changed file matches one of them, each of them must match a changed file
+	Files []string `json:"files"`
+	// When and Require are directional: once a changed file matches When, another changed file must match Require
+	When    []string `json:"when"`
+	Require []string `json:"require"`
+	// Message replaces the default message of comments
+	Message string `json:"message"`
+}
+
+// UnmarshalJSON accepts a plain list of files as well as an object
+func (g *changeGroup) UnmarshalJSON(data []byte) error {
+	var files []string
+	if err := json.Unmarshal(data, &files); err == nil {
+		*g = changeGroup{Files: files}
+		return nil
+	}
+
+	type rawChangeGroup changeGroup
+	raw := rawChangeGroup{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*g = changeGroup(raw)
+	return nil
+}
+
+type httpConfig struct {
+	// TimeoutSeconds is the timeout of a single attempt, 30 by default
+	TimeoutSeconds int `json:"timeoutSeconds"`
+	// MaxRetries is the number of retries after the first attempt, 3 by default, negative disables retries
+	MaxRetries int `json:"maxRetries"`
+	// RetryDelayMilliseconds is the initial backoff, doubled on each retry, 500 by default
+	RetryDelayMilliseconds int `json:"retryDelayMilliseconds"`
+	// MaxRetryDelaySeconds caps backoff and server requested delays, 60 by default
+	MaxRetryDelaySeconds int `json:"maxRetryDelaySeconds"`
+}
+
+// votePolicy maps the aggregated severity of all checks to a vote
+type votePolicy struct {
+	// Error, Warning, Info and Pass are votes for each severity, Pass is used when there is no finding
+	Error   *int `json:"error"`
+	Warning *int `json:"warning"`
+	Info    *int `json:"info"`
+	Pass    *int `json:"pass"`
+	// VoteWithHumanComments allows raising the vote while human comments exist
+	VoteWithHumanComments bool `json:"voteWithHumanComments"`
+}
+
+type statusConfig struct {
+	// Enabled posts a pull request status per check
+	Enabled bool `json:"enabled"`
+	// Genre groups the statuses of the bot, "vsts-pr" by default
+	Genre string `json:"genre"`
+	// TargetURL is linked from statuses, the pull request page by default
+	TargetURL string `json:"targetUrl"`
+}
+
+type summaryConfig struct {
+	// Enabled keeps a summary thread with results of all checks
+	Enabled bool `json:"enabled"`
+}
+
+type incrementalConfig struct {
+	// Enabled reviews file contents only for files changed since the last review, using pull request iterations
+	Enabled bool `json:"enabled"`
+}
+
+// testCompanionRule expects test files to change together with source files
+type testCompanionRule struct {
+	// Sources are globs of source files, e.g. "**/*.go"
+	Sources []string `json:"sources"`
+	// Tests are globs of expected test files with placeholders {dir}, {name} and {ext} of the source file, e.g. "{dir}/{name}_test.go"
+	Tests []string `json:"tests"`
+	// Exclude are globs of files matching Sources that are not checked, e.g. "**/*_test.go"
+	Exclude []string `json:"exclude"`
+}
+
+type testCompanionConfig struct {
+	// Rules are checked in order and the first rule matching a file applies, Go and C# rules by default
+	Rules []testCompanionRule `json:"rules"`
+	// Exclude are globs of files never checked, generated and vendored files by default
+	Exclude []string `json:"exclude"`
+}
+
+type ownersConfig struct {
+	// Path is the ownership file in the target branch, "/CODEOWNERS" by default
+	Path string `json:"path"`
+	// Required adds owners as required reviewers
+	Required bool `json:"required"`
+	// Identities maps owners in the ownership file to reviewer IDs, other owners must be IDs
+	Identities map[string]string `json:"identities"`
+}
+
+// sizeThresholds are limits of the size of a pull request
+type sizeThresholds struct {
+	// Files is the number of changed files
+	Files int `json:"files"`
+	// Lines is the number of added and removed lines
+	Lines int `json:"lines"`
+}
+
+type sizeConfig struct {
+	// Warning thresholds are 50 files and 1000 lines by default, negative disables a threshold
+	Warning sizeThresholds `json:"warning"`
+	// Error thresholds fail the check, 0 disables a threshold
+	Error sizeThresholds `json:"error"`
+	// Exclude are globs of files not counted, generated and vendored files by default
+	Exclude []string `json:"exclude"`
+}
+
+// metadataConfig are rules for titles and descriptions of pull requests
+type metadataConfig struct {
+	// TitlePattern is a regular expression titles must match, e.g. ^(feat|fix|docs)(\(.+\))?: for conventional commits
+	TitlePattern string `json:"titlePattern"`
+	// MinDescriptionLength is the minimum number of characters of descriptions, without surrounding whitespace
+	MinDescriptionLength int `json:"minDescriptionLength"`
+	// RequiredSections are lines descriptions must contain, e.g. "## Testing"
+	RequiredSections []string `json:"requiredSections"`
+	// BannedText is placeholder text, e.g. of the pull request template, descriptions must not contain
+	BannedText []string `json:"bannedText"`
+	// Severity of failing rules, error by default
+	Severity Severity `json:"severity"`
+}
+
+// workItemsConfig are rules for work items linked to pull requests
+type workItemsConfig struct {
+	// AllowedTypes are accepted work item types, all types if empty
+	AllowedTypes []string `json:"allowedTypes"`
+	// DisallowedStates are work item states not accepted, e.g. "Closed"
+	DisallowedStates []string `json:"disallowedStates"`
+	// AutoLink links work items referenced as AB#1234 in title, description or source branch
+	AutoLink bool `json:"autoLink"`
+}
+
+type freshnessConfig struct {
+	// Warning is the number of commits the source branch may be behind the target branch before the check warns, 50 by default, negative disables
+	Warning int `json:"warning"`
+	// Error is the number of commits the source branch may be behind the target branch before the check fails, 0 disables
+	Error int `json:"error"`
+}
+
+type diffsConfig struct {
+	// PageSize is the number of changes requested per page of diffs, 1000 by default
+	PageSize int `json:"pageSize"`
+	// MaxChanges is the number of changes reviewed before a pull request is too large to review fully, 3000 by default, negative disables
+	MaxChanges int `json:"maxChanges"`
+}
 
 // Config is configuration for VSTS access
 type Config struct {
-	Username                 string        `json:"username"`
-	Password                 string        `json:"password"`
-	Instance                 string        `json:"instance"`
-	Collection               string        `json:"collection"`
-	Project                  string        `json:"project"`
-	Repo                     string        `json:"repo"`
-	MasterBranch             string        `json:"masterBranch"`
-	UserID                   string        `json:"userId"`
-	SupportLegacyImageFormat bool          `json:"supportLegacyImageFormat"`
-	ImageConfigs             []imageConfig `json:"imageConfigs"`
-	ChangeGroups             []changeGroup `json:"changeGroups"`
-	StorageEntitiesPrefix    []string      `json:"storageEntitiesPrefix"`
-	Endpoints                []string      `json:"endpoints"`
-}
-
-// GetConfig loads configuration from file
+	Username                 string              `json:"username"`
+	Password                 string              `json:"password"`
+	Scheme                   string              `json:"scheme"`
+	Instance                 string              `json:"instance"`
+	Collection               string              `json:"collection"`
+	APIVersions              map[string]string   `json:"apiVersions"`
+	Project                  string              `json:"project"`
+	Repo                     string              `json:"repo"`
+	MasterBranch             string              `json:"masterBranch"`
+	UserID                   string              `json:"userId"`
+	SupportLegacyImageFormat bool                `json:"supportLegacyImageFormat"`
+	ImageConfigs             []imageConfig       `json:"imageConfigs"`
+	ChangeGroups             []changeGroup       `json:"changeGroups"`
+	StorageEntitiesPrefix    []string            `json:"storageEntitiesPrefix"`
+	TestCompanion            testCompanionConfig `json:"testCompanion"`
+	Owners                   ownersConfig        `json:"owners"`
+	Size                     sizeConfig          `json:"size"`
+	Metadata                 metadataConfig      `json:"metadata"`
+	WorkItems                workItemsConfig     `json:"workItems"`
+	Freshness                freshnessConfig     `json:"freshness"`
+	Diffs                    diffsConfig         `json:"diffs"`
+	Endpoints                []string            `json:"endpoints"`
+	Checks                   map[string]bool     `json:"checks"`
+	VotePolicy               votePolicy          `json:"votePolicy"`
+	Statuses                 statusConfig        `json:"statuses"`
+	Summary                  summaryConfig       `json:"summary"`
+	Incremental              incrementalConfig   `json:"incremental"`
+	Templates                map[string]string   `json:"templates"`
+	CommentPrefix            *string             `json:"commentPrefix"`
+	HTTP                     httpConfig          `json:"http"`
+	ListenAddress            string              `json:"listenAddress"`
+	WebhookUsername          string              `json:"webhookUsername"`
+	WebhookPassword          string              `json:"webhookPassword"`
+	DryRun                   bool                `json:"dryRun"`
+	DryRunOutput             string              `json:"dryRunOutput"`
+}
+
+// GetConfig loads configuration from file in env VSTS_CONFIG_PATH
 func GetConfig() (*Config, error) {
 	configPathString := os.Getenv(configPath)
 	if len(configPathString) == 0 {
 		return nil, fmt.Errorf("env '%s' not found", configPath)
 	}
 
+	return LoadConfig(configPathString)
+}
+
+// LoadConfig loads configuration from file
+func LoadConfig(path string) (*Config, error) {
 	config := Config{}
 
-	file, _ := os.Open(configPathString)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 	defer file.Close()
 	decoder := json.NewDecoder(file)
-	err := decoder.Decode(&config)
+	err = decoder.Decode(&config)
 	if err != nil {
 		return nil, err
 	}
 
+	err = config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration %s: %v", path, err)
+	}
+
 	return &config, nil
 }
+
+// validate rejects settings which would fail every review
+func (c *Config) validate() error {
+	for _, group := range c.ChangeGroups {
+		if err := group.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
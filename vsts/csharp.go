@@ -0,0 +1,320 @@
+package vsts
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// entityProperty is a public instance property of a C# class
+type entityProperty struct {
+	Name string
+	Type string
+	Line int
+	// offset of the name in the source orders properties declared on one line
+	offset int
+}
+
+// entityClass is a C# class with its public instance properties
+type entityClass struct {
+	Name string
+	Line int
+	// Properties are keyed by the name they are stored with, see storedNamePattern
+	Properties map[string]entityProperty
+
+	bodyStart int
+	bodyEnd   int
+	depth     int
+}
+
+var (
+	classPattern    = regexp.MustCompile(`\b(?:class|struct|record)\s+([A-Za-z_]\w*)`)
+	propertyPattern = regexp.MustCompile(`(?m)(?:^|[{;}])[ \t]*((?:\[[^\]\n]*\][ \t]*)*)((?:(?:public|protected|internal|private|static|virtual|override|abstract|new|sealed|required)[ \t]+)*)([A-Za-z_][\w.]*(?:<[^;{}()=]*>)?\??(?:\[[ \t,]*\])*\??)[ \t]+([A-Za-z_]\w*)\s*`)
+
+	// storedNamePattern matches the attribute storing a property under another name, e.g. to rename it in code only
+	storedNamePattern = regexp.MustCompile(`\bDataMember(?:Attribute)?\s*\([^)]*\bName\s*=\s*"([^"]*)"`)
+	nullablePattern   = regexp.MustCompile(`^Nullable<(.+)>$`)
+)
+
+// csharpTypeKeywords declare types, not properties
+var csharpTypeKeywords = map[string]bool{
+	"class":     true,
+	"struct":    true,
+	"record":    true,
+	"interface": true,
+	"enum":      true,
+	"delegate":  true,
+}
+
+var csharpTypeAliases = map[string]string{
+	"boolean":  "bool",
+	"byte":     "byte",
+	"datetime": "DateTime",
+	"double":   "double",
+	"guid":     "Guid",
+	"int16":    "short",
+	"int32":    "int",
+	"int64":    "long",
+	"single":   "float",
+	"string":   "string",
+}
+
+// normalizeCSharpType drops whitespace and the System namespace and maps framework types to keywords
+func normalizeCSharpType(t string) string {
+	t = strings.Replace(strings.Join(strings.Fields(t), ""), "System.", "", -1)
+
+	nullable := ""
+	if match := nullablePattern.FindStringSubmatch(t); match != nil {
+		t, nullable = match[1], "?"
+	} else if strings.HasSuffix(t, "?") {
+		t, nullable = strings.TrimSuffix(t, "?"), "?"
+	}
+	if alias, ok := csharpTypeAliases[strings.ToLower(t)]; ok {
+		t = alias
+	}
+	return t + nullable
+}
+
+// stripCSharpComments blanks comments and string literals, keeping line numbers and offsets
+func stripCSharpComments(source string) string {
+	b := []byte(source)
+	blank := func(i int) {
+		if b[i] != '\n' {
+			b[i] = ' '
+		}
+	}
+
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				blank(i)
+			}
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '*':
+			blank(i)
+			blank(i + 1)
+			for i += 2; i < len(b) && !(b[i] == '*' && i+1 < len(b) && b[i+1] == '/'); i++ {
+				blank(i)
+			}
+			if i < len(b) {
+				blank(i)
+				blank(i + 1)
+				i++
+			}
+		case b[i] == '"' || b[i] == '\'':
+			quote := b[i]
+			verbatim := quote == '"' && i > 0 && b[i-1] == '@'
+			for i++; i < len(b); i++ {
+				if b[i] == '\\' && !verbatim && i+1 < len(b) {
+					blank(i)
+					i++
+				} else if b[i] == quote {
+					if verbatim && i+1 < len(b) && b[i+1] == quote {
+						blank(i)
+						i++
+					} else {
+						break
+					}
+				}
+				blank(i)
+			}
+		}
+	}
+
+	return string(b)
+}
+
+// parseEntityClasses returns the classes of C# source by name
+func parseEntityClasses(source string) map[string]*entityClass {
+	original := source
+	source = stripCSharpComments(source)
+
+	// depths[i] is the brace depth before source[i]
+	depths := make([]int, len(source)+1)
+	depth := 0
+	for i := 0; i < len(source); i++ {
+		depths[i] = depth
+		switch source[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+	}
+	depths[len(source)] = depth
+
+	lineOf := func(offset int) int {
+		return strings.Count(source[:offset], "\n") + 1
+	}
+
+	classes := make(map[string]*entityClass)
+	var ordered []*entityClass
+	for _, match := range classPattern.FindAllStringSubmatchIndex(source, -1) {
+		open := strings.IndexAny(source[match[1]:], "{;")
+		if open < 0 || source[match[1]+open] != '{' {
+			continue
+		}
+		bodyStart := match[1] + open + 1
+		bodyEnd := bodyStart
+		for bodyEnd < len(source) && depths[bodyEnd+1] > depths[bodyStart-1] {
+			bodyEnd++
+		}
+
+		class := &entityClass{
+			Name:       source[match[2]:match[3]],
+			Line:       lineOf(match[0]),
+			Properties: make(map[string]entityProperty),
+			bodyStart:  bodyStart,
+			bodyEnd:    bodyEnd,
+			depth:      depths[bodyStart],
+		}
+		classes[class.Name] = class
+		ordered = append(ordered, class)
+	}
+
+	for _, match := range propertyPattern.FindAllStringSubmatchIndex(source, -1) {
+		// The body is not matched so that a class body can start the next match.
+		// Expression-bodied properties (=>) are computed and not stored.
+		if !strings.HasPrefix(source[match[1]:], "{") {
+			continue
+		}
+		modifiers := strings.Fields(source[match[4]:match[5]])
+		if !containsString(modifiers, "public") || containsString(modifiers, "static") {
+			continue
+		}
+		propertyType := source[match[6]:match[7]]
+		attributesStart := getAttributesStart(source, match[2])
+		if csharpTypeKeywords[propertyType] || isIgnoredProperty(source[attributesStart:match[3]]) {
+			continue
+		}
+
+		nameStart := match[8]
+		var owner *entityClass
+		for _, class := range ordered {
+			if nameStart >= class.bodyStart && nameStart < class.bodyEnd && depths[nameStart] == class.depth {
+				owner = class
+			}
+		}
+		if owner == nil {
+			continue
+		}
+
+		name := source[match[8]:match[9]]
+		storedName := name
+		// string literals are blanked in source, the stored name is read from the original
+		if nameMatch := storedNamePattern.FindStringSubmatch(original[attributesStart:match[3]]); nameMatch != nil && len(nameMatch[1]) > 0 {
+			storedName = nameMatch[1]
+		}
+		owner.Properties[storedName] = entityProperty{
+			Name:   name,
+			Type:   normalizeCSharpType(propertyType),
+			Line:   lineOf(nameStart),
+			offset: nameStart,
+		}
+	}
+
+	return classes
+}
+
+// getAttributesStart returns the offset of the attributes of the property starting at offset,
+// including attributes on preceding lines
+func getAttributesStart(source string, offset int) int {
+	return strings.LastIndexAny(source[:offset], ";{}") + 1
+}
+
+// isIgnoredProperty reports whether the attributes of a property exclude it from storage
+func isIgnoredProperty(attributes string) bool {
+	return strings.Contains(attributes, "IgnoreProperty") || strings.Contains(attributes, "IgnoreDataMember")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// entityChange is a breaking change of a storage entity
+type entityChange struct {
+	Description string
+	// Line is the source line of the change, 0 if it is gone from source
+	Line int
+}
+
+// compareEntityClasses returns the breaking changes from target to source classes:
+// removed classes and properties and changed property types.
+// Renamed properties are removed unless they keep their stored name, see storedNamePattern.
+func compareEntityClasses(target map[string]*entityClass, source map[string]*entityClass) []entityChange {
+	var classNames []string
+	for name := range target {
+		classNames = append(classNames, name)
+	}
+	sort.Strings(classNames)
+
+	var changes []entityChange
+	for _, className := range classNames {
+		oldClass := target[className]
+		newClass, ok := source[className]
+		if !ok {
+			changes = append(changes, entityChange{Description: "`" + className + "` was removed"})
+			continue
+		}
+
+		var removed []entityProperty
+		for _, storedName := range sortedPropertyNames(oldClass) {
+			property := oldClass.Properties[storedName]
+			newProperty, ok := newClass.Properties[storedName]
+			if !ok {
+				removed = append(removed, property)
+				continue
+			}
+			if newProperty.Type != property.Type {
+				changes = append(changes, entityChange{
+					Description: "`" + className + "." + property.Name + "` changed type from `" + property.Type + "` to `" + newProperty.Type + "`",
+					Line:        newProperty.Line,
+				})
+			}
+		}
+
+		// a removed property is renamed when a property of the same type was added in its place
+		var added []string
+		for _, storedName := range sortedPropertyNames(newClass) {
+			if _, ok := oldClass.Properties[storedName]; !ok {
+				added = append(added, storedName)
+			}
+		}
+		for _, property := range removed {
+			change := entityChange{
+				Description: "`" + className + "." + property.Name + "` was removed",
+				Line:        newClass.Line,
+			}
+			for i, storedName := range added {
+				if newClass.Properties[storedName].Type == property.Type {
+					change = entityChange{
+						Description: "`" + className + "." + property.Name + "` was renamed to `" + storedName + "`",
+						Line:        newClass.Properties[storedName].Line,
+					}
+					added = append(added[:i], added[i+1:]...)
+					break
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// sortedPropertyNames returns the stored names of the properties of a class in source order
+func sortedPropertyNames(class *entityClass) []string {
+	var names []string
+	for name := range class.Properties {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return class.Properties[names[i]].offset < class.Properties[names[j]].offset
+	})
+	return names
+}
@@ -0,0 +1,208 @@
+package vsts
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testEntitySource = `using System;
+
+namespace Providers.Data.Entities
+{
+    /// <summary>A user, see "class Fake { }"</summary>
+    public class UserEntity : TableEntity
+    {
+        public const string PartitionName = "users";
+
+        public string Name { get; set; }
+
+        // public int Removed { get; set; }
+        public Int32 Age
+        {
+            get; set;
+        }
+
+        public DateTime? LastLogin { get; set; }
+
+        public Dictionary<string, List<int>> Scores { get; set; }
+
+        [IgnoreProperty]
+        public string DisplayName => Name + "}";
+
+        public static UserEntity Empty { get; } = new UserEntity();
+
+        private string secret { get; set; }
+
+        public void Touch() { LastLogin = DateTime.UtcNow; }
+
+        public int NameLength => Name.Length;
+
+        [DataMember(Name = "mail")]
+        public string Email { get; set; }
+
+        public System.Nullable<System.Int64> Visits { get; set; }
+
+        public class Address
+        {
+            public string Street { get; set; }
+        }
+    }
+}
+`
+
+func TestParseEntityClasses(t *testing.T) {
+	classes := parseEntityClasses(testEntitySource)
+
+	user, ok := classes["UserEntity"]
+	if !ok {
+		t.Fatalf("UserEntity not found: %+v", classes)
+	}
+	want := map[string]entityProperty{
+		"Name":      {Name: "Name", Type: "string", Line: 10},
+		"Age":       {Name: "Age", Type: "int", Line: 13},
+		"LastLogin": {Name: "LastLogin", Type: "DateTime?", Line: 18},
+		"Scores":    {Name: "Scores", Type: "Dictionary<string,List<int>>", Line: 20},
+		"mail":      {Name: "Email", Type: "string", Line: 34},
+		"Visits":    {Name: "Visits", Type: "long?", Line: 36},
+	}
+	got := make(map[string]entityProperty)
+	for name, property := range user.Properties {
+		property.offset = 0
+		got[name] = property
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got properties %+v, want %+v", got, want)
+	}
+
+	address, ok := classes["Address"]
+	if !ok || len(address.Properties) != 1 {
+		t.Errorf("got Address %+v, want one property", address)
+	}
+	if _, ok := classes["Fake"]; ok {
+		t.Error("parsed class from comment")
+	}
+}
+
+func TestCompareEntityClasses(t *testing.T) {
+	target := parseEntityClasses(`class User {
+    public string Id { get; set; }
+    public string Name { get; set; }
+    public int Age { get; set; }
+    public bool Active { get; set; }
+}
+class Legacy {}`)
+
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"comments only", `class User {
+    // the id
+    public string Id { get; set; }
+    public string Name { get; set; } /* full name */
+    public Int32 Age { get; set; }
+    public System.Boolean Active { get; set; }
+}
+class Legacy {}`, nil},
+		{"added property", `class User {
+    public string Id { get; set; }
+    public string Name { get; set; }
+    public int Age { get; set; }
+    public bool Active { get; set; }
+    public string Email { get; set; }
+}
+class Legacy {}`, nil},
+		{"breaking", `class User {
+    public string Id { get; set; }
+    public string FullName { get; set; }
+    public long Age { get; set; }
+}`, []string{
+			"`Legacy` was removed",
+			"`User.Age` changed type from `int` to `long`",
+			"`User.Name` was renamed to `FullName`",
+			"`User.Active` was removed",
+		}},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, change := range compareEntityClasses(target, parseEntityClasses(test.source)) {
+			got = append(got, change.Description)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCompareEntityProperties(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		source string
+		want   []string
+	}{
+		{
+			name:   "renamed",
+			target: `class User { public string Id { get; set; } public string Name { get; set; } }`,
+			source: `class User { public string Id { get; set; } public string Email { get; set; } }`,
+			want:   []string{"`User.Name` was renamed to `Email`"},
+		},
+		{
+			name:   "removed and added of other type",
+			target: `class User { public string Id { get; set; } public string Name { get; set; } }`,
+			source: `class User { public string Id { get; set; } public int Age { get; set; } }`,
+			want:   []string{"`User.Name` was removed"},
+		},
+		{
+			name:   "renamed and removed",
+			target: `class User { public string Name { get; set; } public string Mail { get; set; } }`,
+			source: `class User { public string FullName { get; set; } }`,
+			want:   []string{"`User.Name` was renamed to `FullName`", "`User.Mail` was removed"},
+		},
+		{
+			name:   "renamed keeping stored name",
+			target: `class User { public string Name { get; set; } }`,
+			source: `class User { [DataMember(Name = "Name")] public string FullName { get; set; } }`,
+		},
+		{
+			name:   "renamed with other stored name",
+			target: `class User { public string Name { get; set; } }`,
+			source: `class User { [DataMember(Name = "full_name")] public string Name { get; set; } }`,
+			want:   []string{"`User.Name` was renamed to `full_name`"},
+		},
+		{
+			name:   "nullable forms",
+			target: `class User { public int? Age { get; set; } public Nullable<bool> Active { get; set; } }`,
+			source: `class User { public Nullable<int> Age { get; set; } public System.Boolean? Active { get; set; } }`,
+		},
+		{
+			name:   "nullable changed",
+			target: `class User { public int? Age { get; set; } }`,
+			source: `class User { public int Age { get; set; } }`,
+			want:   []string{"`User.Age` changed type from `int?` to `int`"},
+		},
+		{
+			name:   "expression-bodied member",
+			target: `class User { public string Name { get; set; } public string Display => Name; }`,
+			source: `class User { public string Name { get; set; } }`,
+		},
+		{
+			name:   "stored property made expression-bodied",
+			target: `class User { public string Name { get; set; } }`,
+			source: `class User { public string Name => "fixed"; }`,
+			want:   []string{"`User.Name` was removed"},
+		},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, change := range compareEntityClasses(parseEntityClasses(test.target), parseEntityClasses(test.source)) {
+			got = append(got, change.Description)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
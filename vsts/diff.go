@@ -1,6 +1,9 @@
 package vsts
 
 import (
+	"log"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -8,28 +11,76 @@ func getBranchNameFromRefName(refName string) string {
 	return (strings.SplitAfterN(refName, "/", 3))[2]
 }
 
-func getdiffsURL(baseBranch string, targetBranch string) string {
-	diffsURLTemplate := "https://{instance}/DefaultCollection/{project}/_apis/git/repositories/{repository}/diffs/commits?api-version={version}&targetVersionType=branch&targetVersion={targetBranch}&baseVersionType=branch&baseVersion={baseBranch}"
+const (
+	defaultDiffsPageSize   = 1000
+	defaultDiffsMaxChanges = 3000
+)
+
+func (c diffsConfig) getPageSize() int {
+	if c.PageSize <= 0 {
+		return defaultDiffsPageSize
+	}
+	return c.PageSize
+}
+
+func (c diffsConfig) getMaxChanges() int {
+	if c.MaxChanges == 0 {
+		return defaultDiffsMaxChanges
+	}
+	return c.MaxChanges
+}
+
+func (c *Client) getDiffsURL(base gitVersion, target gitVersion, skip int, top int) string {
+	diffsURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/diffs/commits?api-version={version}&targetVersionType={targetVersionType}&targetVersion={targetVersion}&baseVersionType={baseVersionType}&baseVersion={baseVersion}&$skip={skip}&$top={top}"
 	r := strings.NewReplacer(
-		"{instance}", config.Instance,
-		"{project}", config.Project,
-		"{repository}", config.Repo,
-		"{version}", "1.0",
-		"{baseBranch}", baseBranch,
-		"{targetBranch}", targetBranch)
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
+		"{version}", c.getAPIVersion(apiDiffs),
+		"{baseVersionType}", base.versionType,
+		"{baseVersion}", url.QueryEscape(base.version),
+		"{targetVersionType}", target.versionType,
+		"{targetVersion}", url.QueryEscape(target.version),
+		"{skip}", strconv.Itoa(skip),
+		"{top}", strconv.Itoa(top))
 
 	return r.Replace(diffsURLTemplate)
 }
 
-func getDiffsBetweenBranches(baseBranch string, targetBranch string) (*diffs, error) {
-	diffs := new(diffs)
+// getDiffs pages through the changes from base to target version.
+// AllChangesIncluded is false when there are more changes than configured in Diffs.MaxChanges.
+func (c *Client) getDiffs(base gitVersion, target gitVersion) (*diffs, error) {
+	pageSize := c.config.Diffs.getPageSize()
+	maxChanges := c.config.Diffs.getMaxChanges()
 
-	url := getdiffsURL(baseBranch, targetBranch)
+	var all *diffs
+	for {
+		skip := 0
+		if all != nil {
+			skip = len(all.Changes)
+		}
 
-	err := getFromVsts(url, diffs)
-	if err != nil {
-		return nil, err
-	}
+		page := new(diffs)
+		err := c.getFromVsts(c.getDiffsURL(base, target, skip, pageSize), page)
+		if err != nil {
+			return nil, err
+		}
 
-	return diffs, nil
+		if all == nil {
+			all = page
+		} else {
+			all.Changes = append(all.Changes, page.Changes...)
+			all.AllChangesIncluded = page.AllChangesIncluded
+		}
+
+		if maxChanges > 0 && len(all.Changes) > maxChanges {
+			log.Printf("only reviewing the first %d changes\n", maxChanges)
+			all.Changes = all.Changes[:maxChanges]
+			all.AllChangesIncluded = false
+			return all, nil
+		}
+		if all.AllChangesIncluded || len(page.Changes) == 0 {
+			return all, nil
+		}
+	}
 }
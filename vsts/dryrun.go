@@ -0,0 +1,86 @@
+package vsts
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+)
+
+// RecordedCall is a mutating VSTS call captured in dry-run mode
+type RecordedCall struct {
+	Action string      `json:"action"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Body   interface{} `json:"body"`
+}
+
+// Recorder captures mutating VSTS calls instead of sending them
+type Recorder struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+}
+
+// EnableDryRun routes all mutating calls of the client to the returned recorder
+func (c *Client) EnableDryRun() *Recorder {
+	c.recorder = &Recorder{}
+	return c.recorder
+}
+
+func (r *Recorder) record(method string, url string, v interface{}) {
+	call := RecordedCall{
+		Action: getCallAction(v),
+		Method: method,
+		URL:    url,
+		Body:   v,
+	}
+
+	body, _ := json.MarshalIndent(v, "", "  ")
+	log.Printf("[dry-run] %s: %s %s\n%s\n", call.Action, method, url, body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// Calls returns all recorded calls in order
+func (r *Recorder) Calls() []RecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]RecordedCall, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+// WriteJSON writes all recorded calls as JSON array
+func (r *Recorder) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.Calls())
+}
+
+func getCallAction(v interface{}) string {
+	switch v.(type) {
+	case postThread:
+		return "createThread"
+	case postComment:
+		return "addComment"
+	case patchComment:
+		return "updateComment"
+	case patchThread:
+		return "setThreadStatus"
+	case patchThreadProperties:
+		return "setThreadProperties"
+	case putVote:
+		return "vote"
+	case putReviewer:
+		return "addReviewer"
+	case jsonPatch:
+		return "linkWorkItem"
+	case postStatus:
+		return "setStatus"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,70 @@
+package vsts
+
+import (
+	"path"
+	"strings"
+)
+
+// defaultGeneratedFiles are globs of vendored and generated files, excluded from checks by default
+var defaultGeneratedFiles = []string{
+	"**/vendor/**",
+	"**/generated/**",
+	"**/*.pb.go",
+	"**/*.generated.go",
+	"**/*.Designer.cs",
+	"**/*.g.cs",
+	"**/AssemblyInfo.cs",
+}
+
+// matchGlob reports whether filePath matches pattern. Both are matched
+// case-insensitively and without leading slashes. Segments are matched with
+// path.Match, and a "**" segment matches any number of directories, e.g.
+// "src/**/*.go" matches "/src/main.go" and "/src/pkg/main.go".
+func matchGlob(pattern string, filePath string) bool {
+	patternSegments := strings.Split(strings.ToLower(strings.Trim(pattern, "/")), "/")
+	pathSegments := strings.Split(strings.ToLower(strings.Trim(filePath, "/")), "/")
+	return matchSegments(patternSegments, pathSegments)
+}
+
+func matchSegments(pattern []string, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
+
+// matchAnyGlob reports whether filePath matches any of patterns
+func matchAnyGlob(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeGlob escapes s to match itself literally in a glob
+func escapeGlob(s string) string {
+	return globEscaper.Replace(s)
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
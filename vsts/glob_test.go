@@ -0,0 +1,32 @@
+package vsts
+
+import (
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "/main.go", true},
+		{"*.go", "/pkg/main.go", false},
+		{"**/*.go", "/main.go", true},
+		{"**/*.go", "/pkg/sub/main.go", true},
+		{"src/**", "/src/a/b.txt", true},
+		{"src/**", "/other/a/b.txt", false},
+		{"src/**/test/*.cs", "/src/test/a.cs", true},
+		{"src/**/test/*.cs", "/src/a/b/test/a.cs", true},
+		{"src/**/test/*.cs", "/src/a/b/test/sub/a.cs", false},
+		{"/Src/*Tests.cs", "/src/FooTests.cs", true},
+		{"vendor/**", "/vendor", true},
+		{"[", "/[", false},
+	}
+
+	for _, test := range tests {
+		if got := matchGlob(test.pattern, test.path); got != test.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", test.pattern, test.path, got, test.want)
+		}
+	}
+}
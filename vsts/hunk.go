@@ -0,0 +1,147 @@
+package vsts
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxDiffCells bounds the memory used to diff the changed middle part of a file,
+// larger changes are reported as a single hunk.
+const maxDiffCells = 1 << 22
+
+// maxCachedHunks bounds the number of changes a hunkCache keeps
+const maxCachedHunks = 10000
+
+// hunkCache keeps the hunks of changes across reviews by the blob IDs of both versions,
+// so unchanged files are not fetched again on later events of a pull request
+type hunkCache struct {
+	mu    sync.Mutex
+	hunks map[string][]Hunk
+}
+
+// getHunkCacheKey returns the key of a change in a hunkCache, empty if the change has no blob IDs
+func getHunkCacheKey(change Change) string {
+	if len(change.Item.ObjectID) == 0 && len(change.Item.OriginalObjectID) == 0 {
+		return ""
+	}
+	return change.Item.OriginalObjectID + ".." + change.Item.ObjectID
+}
+
+func (c *hunkCache) get(key string) ([]Hunk, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hunks, ok := c.hunks[key]
+	return hunks, ok
+}
+
+func (c *hunkCache) set(key string, hunks []Hunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hunks == nil || len(c.hunks) >= maxCachedHunks {
+		c.hunks = make(map[string][]Hunk)
+	}
+	c.hunks[key] = hunks
+}
+
+// Hunk is a range of changed lines between target (left) and source (right) version of a file.
+// Starts are 1-based, for an empty side start is the line before which lines were added or removed.
+type Hunk struct {
+	LeftStart  int
+	LeftLines  int
+	RightStart int
+	RightLines int
+}
+
+// RightEnd returns the last line of the hunk in source version, at least RightStart
+func (h Hunk) RightEnd() int {
+	if h.RightLines == 0 {
+		return h.RightStart
+	}
+	return h.RightStart + h.RightLines - 1
+}
+
+func splitLines(content string) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// diffLines computes the changed hunks between left and right lines
+func diffLines(left []string, right []string) []Hunk {
+	prefix := 0
+	for prefix < len(left) && prefix < len(right) && left[prefix] == right[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(left)-prefix && suffix < len(right)-prefix &&
+		left[len(left)-1-suffix] == right[len(right)-1-suffix] {
+		suffix++
+	}
+
+	a := left[prefix : len(left)-suffix]
+	b := right[prefix : len(right)-suffix]
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	if len(a)*len(b) > maxDiffCells {
+		return []Hunk{{prefix + 1, len(a), prefix + 1, len(b)}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var hunks []Hunk
+	var current *Hunk
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			if current != nil {
+				hunks = append(hunks, *current)
+				current = nil
+			}
+			i++
+			j++
+			continue
+		}
+
+		if current == nil {
+			current = &Hunk{LeftStart: prefix + i + 1, RightStart: prefix + j + 1}
+		}
+		if j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]) {
+			current.LeftLines++
+			i++
+		} else {
+			current.RightLines++
+			j++
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+
+	return hunks
+}
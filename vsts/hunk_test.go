@@ -0,0 +1,31 @@
+package vsts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		left  string
+		right string
+		want  []Hunk
+	}{
+		{"equal", "a\nb\n", "a\nb\n", nil},
+		{"added file", "", "a\nb\n", []Hunk{{1, 0, 1, 2}}},
+		{"deleted file", "a\nb\n", "", []Hunk{{1, 2, 1, 0}}},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", []Hunk{{2, 1, 2, 1}}},
+		{"inserted lines", "a\nc\n", "a\nb1\nb2\nc\n", []Hunk{{2, 0, 2, 2}}},
+		{"removed line", "a\nb\nc\n", "a\nc\n", []Hunk{{2, 1, 2, 0}}},
+		{"two hunks", "a\nb\nc\nd\ne\n", "A\nb\nc\nd\ne\nf\n", []Hunk{{1, 1, 1, 1}, {6, 0, 6, 1}}},
+		{"crlf", "a\r\nb\r\n", "a\nb\n", nil},
+	}
+
+	for _, test := range tests {
+		got := diffLines(splitLines(test.left), splitLines(test.right))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package vsts
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+)
+
+// reviewStateProperty is the hidden property of the summary thread keeping the state of the last review
+const reviewStateProperty = "VstsPr.ReviewState"
+
+// reviewState is what incremental reviews remember of the last review
+type reviewState struct {
+	Iteration    int    `json:"iteration"`
+	SourceCommit string `json:"sourceCommit"`
+	TargetCommit string `json:"targetCommit"`
+	// Config is a hash of the configuration, any change of it requires a full review
+	Config string `json:"config"`
+	// Findings are the findings of all checks by name
+	Findings map[string][]Finding `json:"findings"`
+}
+
+// IncrementalReviewer is a Reviewer whose findings on a file only depend on that file.
+// In incremental reviews it only reviews ReviewContext.ReviewChanges,
+// findings of the last review on other changed files are kept.
+type IncrementalReviewer interface {
+	Reviewer
+	// Incremental reports whether the check supports incremental reviews
+	Incremental() bool
+}
+
+// getConfigHash hashes the settings affecting findings and comments.
+// The hash is readable on the pull request, so credentials and connection settings are left out.
+func getConfigHash(config *Config) (string, error) {
+	content, err := json.Marshal(struct {
+		SupportLegacyImageFormat bool
+		ImageConfigs             []imageConfig
+		ChangeGroups             []changeGroup
+		StorageEntitiesPrefix    []string
+		TestCompanion            testCompanionConfig
+		Owners                   ownersConfig
+		Size                     sizeConfig
+		Metadata                 metadataConfig
+		WorkItems                workItemsConfig
+		Freshness                freshnessConfig
+		Diffs                    diffsConfig
+		Endpoints                []string
+		Checks                   map[string]bool
+		VotePolicy               votePolicy
+		Templates                map[string]string
+		CommentPrefix            *string
+	}{
+		config.SupportLegacyImageFormat,
+		config.ImageConfigs,
+		config.ChangeGroups,
+		config.StorageEntitiesPrefix,
+		config.TestCompanion,
+		config.Owners,
+		config.Size,
+		config.Metadata,
+		config.WorkItems,
+		config.Freshness,
+		config.Diffs,
+		config.Endpoints,
+		config.Checks,
+		config.VotePolicy,
+		config.Templates,
+		config.CommentPrefix,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha1.Sum(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// getLastReviewState reads the state of the last review from the summary thread, nil if there is none
+func (c *Client) getLastReviewState(pullRequestID int) (*reviewState, error) {
+	commentThreads, err := c.getCommentThreads(pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := c.findBotThread(commentThreads, summaryMarker, getSummaryCommentPrefix())
+	if thread == nil {
+		return nil, nil
+	}
+
+	value := thread.Properties.getString(reviewStateProperty)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	state := &reviewState{}
+	err = json.Unmarshal([]byte(value), state)
+	if err != nil {
+		log.Printf("ignoring invalid review state of PR %v: %v\n", pullRequestID, err)
+		return nil, nil
+	}
+
+	return state, nil
+}
+
+// startIncrementalReview limits the files to review to the files changed since the last review
+// and returns the state of this review, the findings are left to fill in
+func (c *Client) startIncrementalReview(ctx *ReviewContext) (*reviewState, error) {
+	pullRequestID := ctx.PullRequest.Resource.PullRequestID
+
+	configHash, err := getConfigHash(c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceCommit := ""
+	if ctx.sourceVersion.versionType == "commit" {
+		sourceCommit = ctx.sourceVersion.version
+	}
+	iteration, err := c.getIteration(pullRequestID, sourceCommit)
+	if err != nil {
+		return nil, err
+	}
+	if iteration == nil {
+		log.Printf("PR %v has no iterations, reviewing all files\n", pullRequestID)
+		return nil, nil
+	}
+
+	state := &reviewState{
+		Iteration:    iteration.ID,
+		SourceCommit: iteration.SourceRefCommit.CommitID,
+		TargetCommit: iteration.TargetRefCommit.CommitID,
+		Config:       configHash,
+		Findings:     make(map[string][]Finding),
+	}
+
+	last, err := c.getLastReviewState(pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case last == nil || last.Iteration == 0:
+		log.Printf("PR %v was not reviewed before, reviewing all files\n", pullRequestID)
+		return state, nil
+	case last.Config != state.Config:
+		log.Printf("configuration changed since last review of PR %v, reviewing all files\n", pullRequestID)
+		return state, nil
+	case last.TargetCommit != state.TargetCommit:
+		log.Printf("target branch of PR %v moved since last review, reviewing all files\n", pullRequestID)
+		return state, nil
+	}
+
+	ctx.reviewPaths = make(map[string]bool)
+	ctx.previousFindings = last.Findings
+	if last.SourceCommit == state.SourceCommit {
+		log.Printf("PR %v source commit %s was already reviewed\n", pullRequestID, state.SourceCommit)
+		return state, nil
+	}
+
+	paths, err := c.getIterationChangedPaths(pullRequestID, iteration.ID, last.Iteration)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		ctx.reviewPaths[path] = true
+	}
+	log.Printf("reviewing %d files changed in PR %v since iteration %d\n", len(ctx.reviewPaths), pullRequestID, last.Iteration)
+
+	return state, nil
+}
+
+// keepPreviousFindings adds the findings of the last review on files which are still changed but were not reviewed again
+func (ctx *ReviewContext) keepPreviousFindings(name string, result *Result) {
+	if ctx.reviewPaths == nil {
+		return
+	}
+
+	changed := make(map[string]bool)
+	for _, change := range ctx.diffs.Changes {
+		changed[change.Item.Path] = true
+	}
+
+	for _, finding := range ctx.previousFindings[name] {
+		if len(finding.FilePath) > 0 && !ctx.reviewPaths[finding.FilePath] && changed[finding.FilePath] {
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+}
@@ -1,28 +1,70 @@
 package vsts
 
 import (
+	"net/url"
 	"strings"
 )
 
-func getBranchItemURL(branch string, itemPath string) string {
-	itemURLTemplate := "https://{instance}/DefaultCollection/{project}/_apis/git/repositories/{repository}/items?api-version={version}&versionType={versionType}&version={versionValue}&scopePath={itemPath}&lastProcessedChange=true"
+// gitVersion identifies a version of the repository, e.g. a branch or a commit
+type gitVersion struct {
+	versionType string
+	version     string
+}
+
+func branchVersion(branch string) gitVersion {
+	return gitVersion{"branch", branch}
+}
+
+func commitVersion(commitID string) gitVersion {
+	return gitVersion{"commit", commitID}
+}
+
+// getSourceVersion returns the source commit the pull request event was raised for,
+// the source branch if the payload has no merge commits yet
+func getSourceVersion(pr *PullRequest) gitVersion {
+	if commitID := pr.Resource.LastMergeSourceCommit.CommitID; len(commitID) > 0 {
+		return commitVersion(commitID)
+	}
+	return branchVersion(getBranchNameFromRefName(pr.Resource.SourceRefName))
+}
+
+// getTargetVersion returns the target commit the pull request event was raised for,
+// the target branch if the payload has no merge commits yet
+func getTargetVersion(pr *PullRequest) gitVersion {
+	if commitID := pr.Resource.LastMergeTargetCommit.CommitID; len(commitID) > 0 {
+		return commitVersion(commitID)
+	}
+	return branchVersion(getBranchNameFromRefName(pr.Resource.TargetRefName))
+}
+
+// getBaseVersion returns the merge base of source and target the diffs were computed from,
+// the target version if the diffs have no common commit
+func getBaseVersion(diffs *diffs, target gitVersion) gitVersion {
+	if len(diffs.CommonCommit) > 0 {
+		return commitVersion(diffs.CommonCommit)
+	}
+	return target
+}
+
+func (c *Client) getItemURL(version gitVersion, itemPath string) string {
+	itemURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/items?api-version={version}&versionType={versionType}&version={versionValue}&scopePath={itemPath}&lastProcessedChange=true"
 	r := strings.NewReplacer(
-		"{instance}", config.Instance,
-		"{project}", config.Project,
-		"{repository}", config.Repo,
-		"{versionType}", "branch",
-		"{versionValue}", branch,
-		"{itemPath}", itemPath,
-		"{version}", "1.0")
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
+		"{versionType}", version.versionType,
+		"{versionValue}", url.QueryEscape(version.version),
+		"{itemPath}", url.QueryEscape(itemPath),
+		"{version}", c.getAPIVersion(apiItems))
 
 	return r.Replace(itemURLTemplate)
 
 }
 
-func getBranchItemContent(branch string, itemPath string, v interface{}) error {
+func (c *Client) getItemContent(version gitVersion, itemPath string, v interface{}) error {
 
-	url := getBranchItemURL(branch, itemPath)
-	err := getFromVsts(url, v)
+	url := c.getItemURL(version, itemPath)
+	err := c.getFromVsts(url, v)
 
 	if err != nil {
 		return err
@@ -30,3 +72,17 @@ func getBranchItemContent(branch string, itemPath string, v interface{}) error {
 
 	return nil
 }
+
+// getItemText returns raw content of a file, found is false if the file does not exist in version
+func (c *Client) getItemText(version gitVersion, itemPath string) (content string, found bool, err error) {
+	url := c.getItemURL(version, itemPath)
+	b, err := c.getRawFromVsts(url)
+	if isNotFound(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(b), true, nil
+}
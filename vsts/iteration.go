@@ -0,0 +1,94 @@
+package vsts
+
+import (
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// iterationChangesPageSize is the number of changes requested per page of iteration changes
+const iterationChangesPageSize = 2000
+
+func (c *Client) getIterationsURL(pullRequestID int) string {
+	iterationsURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/iterations?api-version={version}"
+	r := strings.NewReplacer(
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
+		"{pullRequest}", strconv.Itoa(pullRequestID),
+		"{version}", c.getAPIVersion(apiIterations))
+
+	return r.Replace(iterationsURLTemplate)
+}
+
+func (c *Client) getIterationChangesURL(pullRequestID int, iterationID int, compareTo int, skip int) string {
+	changesURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/iterations/{iteration}/changes?$compareTo={compareTo}&$top={top}&$skip={skip}&api-version={version}"
+	r := strings.NewReplacer(
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
+		"{pullRequest}", strconv.Itoa(pullRequestID),
+		"{iteration}", strconv.Itoa(iterationID),
+		"{compareTo}", strconv.Itoa(compareTo),
+		"{top}", strconv.Itoa(iterationChangesPageSize),
+		"{skip}", strconv.Itoa(skip),
+		"{version}", c.getAPIVersion(apiIterations))
+
+	return r.Replace(changesURLTemplate)
+}
+
+// getIteration returns the latest iteration of a pull request with the source commit,
+// the latest iteration if sourceCommit is empty or not found and nil if there are no iterations
+func (c *Client) getIteration(pullRequestID int, sourceCommit string) (*pullRequestIteration, error) {
+	iterations := pullRequestIterations{}
+	err := c.getFromVsts(c.getIterationsURL(pullRequestID), &iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest, latestOfCommit *pullRequestIteration
+	for i, iteration := range iterations.Value {
+		if latest == nil || iteration.ID > latest.ID {
+			latest = &iterations.Value[i]
+		}
+		if len(sourceCommit) > 0 && strings.EqualFold(iteration.SourceRefCommit.CommitID, sourceCommit) &&
+			(latestOfCommit == nil || iteration.ID > latestOfCommit.ID) {
+			latestOfCommit = &iterations.Value[i]
+		}
+	}
+
+	if latestOfCommit != nil {
+		return latestOfCommit, nil
+	}
+	if len(sourceCommit) > 0 && latest != nil {
+		log.Printf("no iteration of PR %v has source commit %s, using iteration %d\n", pullRequestID, sourceCommit, latest.ID)
+	}
+	return latest, nil
+}
+
+// getIterationChangedPaths returns the paths changed in an iteration since iteration compareTo,
+// renamed files are returned with both paths
+func (c *Client) getIterationChangedPaths(pullRequestID int, iterationID int, compareTo int) ([]string, error) {
+	var paths []string
+	skip := 0
+	for {
+		changes := iterationChanges{}
+		err := c.getFromVsts(c.getIterationChangesURL(pullRequestID, iterationID, compareTo, skip), &changes)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range changes.ChangeEntries {
+			paths = append(paths, change.Item.Path)
+			if len(change.OriginalPath) > 0 {
+				paths = append(paths, change.OriginalPath)
+			}
+		}
+
+		if changes.NextTop == 0 || changes.NextSkip <= skip {
+			return paths, nil
+		}
+		skip = changes.NextSkip
+	}
+}
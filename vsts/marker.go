@@ -0,0 +1,205 @@
+package vsts
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Hidden thread properties identifying bot threads
+const (
+	markerCheckProperty   = "VstsPr.Check"
+	markerKeyProperty     = "VstsPr.Key"
+	markerVersionProperty = "VstsPr.MarkerVersion"
+	markerVersion         = "1"
+	// markerResolvedProperty is "true" while the bot resolved its thread, threads resolved by humans are not reactivated
+	markerResolvedProperty = "VstsPr.Resolved"
+	// markerRepliesProperty lists the IDs of the replies of the bot in its thread, separated by commas
+	markerRepliesProperty = "VstsPr.Replies"
+)
+
+// threadMarker identifies the thread of a check.
+// Key is the file path of file threads and empty for general threads.
+type threadMarker struct {
+	Check string
+	Key   string
+}
+
+func (m threadMarker) properties() threadProperties {
+	return threadProperties{
+		markerCheckProperty:   {Type: "System.String", Value: m.Check},
+		markerKeyProperty:     {Type: "System.String", Value: m.Key},
+		markerVersionProperty: {Type: "System.String", Value: markerVersion},
+	}
+}
+
+// getThreadMarker returns the marker of a thread, ok is false for threads without marker of a known version
+func getThreadMarker(thread commentThread) (marker threadMarker, ok bool) {
+	if thread.Properties.getString(markerVersionProperty) != markerVersion {
+		return threadMarker{}, false
+	}
+	return threadMarker{
+		Check: thread.Properties.getString(markerCheckProperty),
+		Key:   thread.Properties.getString(markerKeyProperty),
+	}, true
+}
+
+// getCommentPrefix returns the visible prefix of the comments of a check,
+// Config.CommentPrefix with {check} replaced by the check name if it is set
+func (c *Client) getCommentPrefix(check string, defaultPrefix string) string {
+	if c.config.CommentPrefix == nil {
+		return defaultPrefix
+	}
+	return strings.Replace(*c.config.CommentPrefix, "{check}", check, -1)
+}
+
+// isBotAuthor reports whether the bot started the thread
+func (c *Client) isBotAuthor(thread commentThread) bool {
+	for _, comment := range thread.Comments {
+		if comment.ID == 1 {
+			return strings.EqualFold(comment.Author.ID, c.config.UserID)
+		}
+	}
+	return false
+}
+
+// getBotThreadMarker returns the marker of a bot thread of check.
+// Threads created before markers were introduced are recognized by the legacy prefix of their first comment.
+func (c *Client) getBotThreadMarker(thread commentThread, check string, legacyPrefix string) (threadMarker, bool) {
+	if thread.IsDeleted || !c.isBotAuthor(thread) {
+		return threadMarker{}, false
+	}
+
+	if marker, ok := getThreadMarker(thread); ok {
+		return marker, marker.Check == check
+	}
+
+	for _, comment := range thread.Comments {
+		if comment.ID == 1 && strings.HasPrefix(comment.Content, legacyPrefix) {
+			return threadMarker{Check: check, Key: thread.ThreadContext.FilePath}, true
+		}
+	}
+	return threadMarker{}, false
+}
+
+// getLegacyCommentPrefix returns the default prefix of the check which created a bot thread before markers,
+// ok is false for marked threads and threads not created by the bot
+func (c *Client) getLegacyCommentPrefix(thread commentThread) (prefix string, ok bool) {
+	if _, marked := getThreadMarker(thread); marked {
+		return "", false
+	}
+
+	prefixes := []string{getSummaryCommentPrefix()}
+	for _, reviewer := range Reviewers() {
+		prefixes = append(prefixes, getDefaultCommentPrefix(reviewer))
+	}
+	for _, prefix := range prefixes {
+		if _, ok := c.getBotThreadMarker(thread, "", prefix); ok {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// getBotReplyIDs returns the IDs of the replies of the bot in its thread.
+// Replies in threads created before markers are recognized by the legacy prefix of the thread.
+func (c *Client) getBotReplyIDs(thread commentThread) []int {
+	var ids []int
+	if _, marked := getThreadMarker(thread); marked {
+		for _, value := range strings.Split(thread.Properties.getString(markerRepliesProperty), ",") {
+			if id, err := strconv.Atoi(value); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+
+	prefix, ok := c.getLegacyCommentPrefix(thread)
+	if !ok {
+		return nil
+	}
+	for _, comment := range thread.Comments {
+		if comment.ID != 1 && strings.EqualFold(comment.Author.ID, c.config.UserID) && strings.HasPrefix(comment.Content, prefix) {
+			ids = append(ids, comment.ID)
+		}
+	}
+	return ids
+}
+
+// getBotRepliesProperty returns the thread property listing the replies of the bot
+func getBotRepliesProperty(ids []int) threadProperty {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return threadProperty{Type: "System.String", Value: strings.Join(values, ",")}
+}
+
+// getBotResolvedProperty returns the thread property recording whether the bot resolved its thread
+func getBotResolvedProperty(resolved bool) threadProperty {
+	return threadProperty{Type: "System.String", Value: strconv.FormatBool(resolved)}
+}
+
+// isResolvedByBot reports whether the bot resolved its thread
+func isResolvedByBot(thread commentThread) bool {
+	return thread.Properties.getString(markerResolvedProperty) == "true"
+}
+
+// getBotThreadProperties returns the marker properties of a bot thread, keeping the replies of the bot
+func (c *Client) getBotThreadProperties(thread commentThread, marker threadMarker) threadProperties {
+	properties := marker.properties()
+	if ids := c.getBotReplyIDs(thread); len(ids) > 0 {
+		properties[markerRepliesProperty] = getBotRepliesProperty(ids)
+	}
+	return properties
+}
+
+// findBotThread returns the bot thread with the marker, nil if there is none
+func (c *Client) findBotThread(threads *commentThreads, marker threadMarker, legacyPrefix string) *commentThread {
+	for i, thread := range threads.Value {
+		if threadMarker, ok := c.getBotThreadMarker(thread, marker.Check, legacyPrefix); ok && strings.EqualFold(threadMarker.Key, marker.Key) {
+			return &threads.Value[i]
+		}
+	}
+	return nil
+}
+
+// updateBotThread creates a general thread with the marker, or updates the first comment of thread in place and sets its status.
+// Threads without marker get it on update.
+func (c *Client) updateBotThread(pullRequestID int, marker threadMarker, thread *commentThread, content string, status int, properties threadProperties) (int, error) {
+	allProperties := marker.properties()
+	if thread != nil {
+		allProperties = c.getBotThreadProperties(*thread, marker)
+	}
+	for name, property := range properties {
+		allProperties[name] = property
+	}
+
+	if thread == nil {
+		createdThread, err := c.createCommentThreadWithProperties(pullRequestID, getFileThreadContext(""), status, content, allProperties)
+		if err != nil {
+			return 0, err
+		}
+		return createdThread.ID, nil
+	}
+
+	for _, comment := range thread.Comments {
+		if comment.ID == 1 && comment.Content != content {
+			err := c.updateComment(pullRequestID, thread.ID, comment.ID, content)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	err := c.setCommentThreadStatus(pullRequestID, *thread, status)
+	if err != nil {
+		return 0, err
+	}
+
+	err = c.setCommentThreadProperties(pullRequestID, *thread, allProperties)
+	if err != nil {
+		return 0, err
+	}
+
+	return thread.ID, nil
+}
@@ -0,0 +1,51 @@
+package vsts
+
+import (
+	"strings"
+)
+
+const defaultOwnersPath = "/CODEOWNERS"
+
+// ownerRule assigns owners to files matching a CODEOWNERS pattern
+type ownerRule struct {
+	pattern string
+	owners  []string
+}
+
+// parseOwners parses lines of "pattern owner..." and skips comments and empty lines
+func parseOwners(content string) []ownerRule {
+	var rules []ownerRule
+	for _, line := range splitLines(content) {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, ownerRule{pattern: fields[0], owners: fields[1:]})
+	}
+	return rules
+}
+
+// matches follows CODEOWNERS semantics: patterns with a leading or inner slash are
+// relative to the repository root, others match at any depth, and a pattern
+// matching a directory matches all files below it
+func (r ownerRule) matches(filePath string) bool {
+	glob := r.pattern
+	if !strings.Contains(strings.TrimSuffix(glob, "/"), "/") {
+		glob = "**/" + glob
+	}
+	glob = strings.TrimSuffix(glob, "/")
+	return matchGlob(glob, filePath) || matchGlob(glob+"/**", filePath)
+}
+
+// getOwners returns the owners of the last rule matching filePath, as in CODEOWNERS
+func getOwners(rules []ownerRule, filePath string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].matches(filePath) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
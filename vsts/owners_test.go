@@ -0,0 +1,36 @@
+package vsts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetOwners(t *testing.T) {
+	rules := parseOwners(`# default owners
+*                  @everyone
+
+*.cs               @dotnet
+/docs/             @writers   # documentation
+src/Storage        @storage @dba
+/build/**/*.yml    @build
+`)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/README.md", []string{"@everyone"}},
+		{"/src/app/Program.cs", []string{"@dotnet"}},
+		{"/docs/guide/intro.md", []string{"@writers"}},
+		{"/tools/docs/intro.md", []string{"@everyone"}},
+		{"/src/Storage/Entities/User.cs", []string{"@storage", "@dba"}},
+		{"/lib/src/Storage/User.cs", []string{"@dotnet"}},
+		{"/build/ci/pipeline.yml", []string{"@build"}},
+	}
+
+	for _, test := range tests {
+		if got := getOwners(rules, test.path); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("getOwners(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -71,28 +73,9 @@ type PullRequest struct {
 			Comment string `json:"comment"`
 			URL     string `json:"url"`
 		} `json:"lastMergeCommit"`
-		Reviewers []struct {
-			ReviewerURL string `json:"reviewerUrl"`
-			Vote        int    `json:"vote"`
-			DisplayName string `json:"displayName"`
-			URL         string `json:"url"`
-			ID          string `json:"id"`
-			UniqueName  string `json:"uniqueName"`
-			ImageURL    string `json:"imageUrl"`
-			IsContainer bool   `json:"isContainer,omitempty"`
-			VotedFor    []struct {
-				ReviewerURL string `json:"reviewerUrl"`
-				Vote        int    `json:"vote"`
-				DisplayName string `json:"displayName"`
-				URL         string `json:"url"`
-				ID          string `json:"id"`
-				UniqueName  string `json:"uniqueName"`
-				ImageURL    string `json:"imageUrl"`
-				IsContainer bool   `json:"isContainer"`
-			} `json:"votedFor,omitempty"`
-		} `json:"reviewers"`
-		URL   string `json:"url"`
-		Links struct {
+		Reviewers []IdentityRefWithVote `json:"reviewers"`
+		URL       string                `json:"url"`
+		Links     struct {
 			Web struct {
 				Href string `json:"href"`
 			} `json:"web"`
@@ -121,6 +104,29 @@ type PullRequest struct {
 	CreatedDate time.Time `json:"createdDate"`
 }
 
+// IdentityRefWithVote is a reviewer of a pull request
+type IdentityRefWithVote struct {
+	ReviewerURL string `json:"reviewerUrl"`
+	Vote        int    `json:"vote"`
+	DisplayName string `json:"displayName"`
+	URL         string `json:"url"`
+	ID          string `json:"id"`
+	UniqueName  string `json:"uniqueName"`
+	ImageURL    string `json:"imageUrl"`
+	IsContainer bool   `json:"isContainer,omitempty"`
+	IsRequired  bool   `json:"isRequired,omitempty"`
+	VotedFor    []struct {
+		ReviewerURL string `json:"reviewerUrl"`
+		Vote        int    `json:"vote"`
+		DisplayName string `json:"displayName"`
+		URL         string `json:"url"`
+		ID          string `json:"id"`
+		UniqueName  string `json:"uniqueName"`
+		ImageURL    string `json:"imageUrl"`
+		IsContainer bool   `json:"isContainer"`
+	} `json:"votedFor,omitempty"`
+}
+
 // ParsePullRequest parse pull request from encoded string
 func ParsePullRequest() (*PullRequest, error) {
 	encodedPRContentString := os.Getenv("PR_CONTENT")
@@ -133,8 +139,28 @@ func ParsePullRequest() (*PullRequest, error) {
 		return nil, err
 	}
 
+	return parsePullRequest(prContentBytes)
+}
+
+// ReadPullRequest parse pull request from service hook payload
+func ReadPullRequest(r io.Reader) (*PullRequest, error) {
+	prContentBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePullRequest(prContentBytes)
+}
+
+func parsePullRequest(prContentBytes []byte) (*PullRequest, error) {
 	prContentString := string(prContentBytes)
-	prContentRaw := prContentString[strings.Index(prContentString, "{"):(strings.LastIndex(prContentString, "}") + 1)]
+	start := strings.Index(prContentString, "{")
+	end := strings.LastIndex(prContentString, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("PR content is not JSON: %v", prContentString)
+	}
+
+	prContentRaw := prContentString[start:(end + 1)]
 	prContent := PullRequest{}
 	if err := json.Unmarshal([]byte(prContentRaw), &prContent); err != nil {
 		return nil, err
@@ -144,5 +170,23 @@ func ParsePullRequest() (*PullRequest, error) {
 		return nil, fmt.Errorf("PR ID is empty: %v", prContentString)
 	}
 
+	if strings.HasPrefix(strings.ToLower(prContent.EventType), "git.pullrequest.") {
+		for _, refName := range []string{prContent.Resource.SourceRefName, prContent.Resource.TargetRefName} {
+			if !isBranchRefName(refName) {
+				return nil, fmt.Errorf("PR %v has no branch ref name: '%s'", prContent.Resource.PullRequestID, refName)
+			}
+		}
+	}
+
 	return &prContent, nil
 }
+
+// isBranchRefName reports whether refName is a full ref name of a branch like refs/heads/master
+func isBranchRefName(refName string) bool {
+	return strings.HasPrefix(refName, "refs/heads/") && len(refName) > len("refs/heads/")
+}
+
+// IsTargetBranch checks whether pull request targets the given branch
+func (pr *PullRequest) IsTargetBranch(branch string) bool {
+	return strings.EqualFold(pr.Resource.TargetRefName, fmt.Sprintf("%s/%s", "refs/heads", branch))
+}
@@ -0,0 +1,200 @@
+package vsts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRequestTimeout = 30 * time.Second
+	defaultMaxRetries     = 3
+	defaultRetryDelay     = 500 * time.Millisecond
+	defaultMaxRetryDelay  = 60 * time.Second
+)
+
+func (h httpConfig) timeout() time.Duration {
+	if h.TimeoutSeconds > 0 {
+		return time.Duration(h.TimeoutSeconds) * time.Second
+	}
+	return defaultRequestTimeout
+}
+
+func (h httpConfig) maxRetries() int {
+	if h.MaxRetries < 0 {
+		return 0
+	}
+	if h.MaxRetries == 0 {
+		return defaultMaxRetries
+	}
+	return h.MaxRetries
+}
+
+func (h httpConfig) retryDelay() time.Duration {
+	if h.RetryDelayMilliseconds > 0 {
+		return time.Duration(h.RetryDelayMilliseconds) * time.Millisecond
+	}
+	return defaultRetryDelay
+}
+
+func (h httpConfig) maxRetryDelay() time.Duration {
+	if h.MaxRetryDelaySeconds > 0 {
+		return time.Duration(h.MaxRetryDelaySeconds) * time.Second
+	}
+	return defaultMaxRetryDelay
+}
+
+// throttle delays requests while VSTS asks the client to slow down
+type throttle struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (t *throttle) wait() {
+	t.mu.Lock()
+	d := time.Until(t.until)
+	t.mu.Unlock()
+
+	if d > 0 {
+		log.Printf("Throttled by VSTS, waiting %v...\n", d)
+		time.Sleep(d)
+	}
+}
+
+func (t *throttle) delay(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(t.until) {
+		t.until = until
+	}
+}
+
+// do sends request with retries, the caller must close the response body.
+// POST is not idempotent, thus only retried when VSTS did not process the request (429, 503).
+func (c *Client) do(method string, url string, body []byte, contentType string) (*http.Response, error) {
+	h := c.config.HTTP
+	backoff := h.retryDelay()
+
+	for attempt := 0; ; attempt++ {
+		c.throttle.wait()
+
+		resp, err := c.attempt(method, url, body, contentType)
+
+		retryable := false
+		delay := backoff
+		if err != nil {
+			retryable = method != http.MethodPost
+		} else {
+			serverDelay, throttled := getRetryAfter(resp)
+			if serverDelay > h.maxRetryDelay() {
+				serverDelay = h.maxRetryDelay()
+			}
+			if throttled && resp.StatusCode < 300 {
+				// VSTS delayed the request, slow down following requests.
+				c.throttle.delay(serverDelay)
+			}
+
+			switch {
+			case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+				retryable = true
+			case resp.StatusCode >= 500:
+				retryable = method != http.MethodPost
+			}
+
+			if retryable && throttled {
+				delay = serverDelay
+			}
+		}
+
+		if !retryable || attempt >= h.maxRetries() {
+			return resp, err
+		}
+
+		if delay < 0 {
+			delay = 0
+		}
+		if delay > h.maxRetryDelay() {
+			delay = h.maxRetryDelay()
+		}
+		// jitter avoids retrying in lockstep with other clients
+		delay += time.Duration(rand.Int63n(int64(delay)/4 + 1))
+
+		if err != nil {
+			log.Printf("%s %s failed: %v, retry in %v\n", method, url, err, delay)
+		} else {
+			log.Printf("%s %s failed: %s, retry in %v\n", method, url, resp.Status, delay)
+			resp.Body.Close()
+		}
+
+		c.throttle.delay(delay)
+		backoff *= 2
+	}
+}
+
+func (c *Client) attempt(method string, url string, body []byte, contentType string) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.HTTP.timeout())
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	req.SetBasicAuth(c.config.Username, c.config.Password)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// getRetryAfter reads the delay requested by VSTS from Retry-After or X-RateLimit-Reset
+func getRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if v := resp.Header.Get("X-RateLimit-Reset"); v != "" {
+			if epoch, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return time.Until(time.Unix(epoch, 0)), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,159 @@
+package vsts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetRetryAfter(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        map[string]string
+		wantMin       time.Duration
+		wantMax       time.Duration
+		wantThrottled bool
+	}{
+		{"none", nil, 0, 0, false},
+		{"seconds", map[string]string{"Retry-After": "5"}, 5 * time.Second, 5 * time.Second, true},
+		{"http date", map[string]string{"Retry-After": time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}, 58 * time.Second, time.Minute, true},
+		{"invalid", map[string]string{"Retry-After": "soon"}, 0, 0, false},
+		{"rate limit reset", map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10),
+		}, 58 * time.Second, time.Minute, true},
+		{"rate limit remaining", map[string]string{
+			"X-RateLimit-Remaining": "10",
+			"X-RateLimit-Reset":     strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10),
+		}, 0, 0, false},
+		{"retry after wins", map[string]string{
+			"Retry-After":           "2",
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10),
+		}, 2 * time.Second, 2 * time.Second, true},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		for key, value := range test.header {
+			resp.Header.Set(key, value)
+		}
+
+		delay, throttled := getRetryAfter(resp)
+		if throttled != test.wantThrottled || delay < test.wantMin || delay > test.wantMax {
+			t.Errorf("%s: getRetryAfter = %v, %v, want %v-%v, %v", test.name, delay, throttled, test.wantMin, test.wantMax, test.wantThrottled)
+		}
+	}
+}
+
+// newTestRetryServer answers requests with the statuses in order and the last status after that
+func newTestRetryServer(t *testing.T, statuses []int, header map[string]string) (*httptest.Server, func() []time.Time) {
+	var mu sync.Mutex
+	var attempts []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		i := len(attempts)
+		attempts = append(attempts, time.Now())
+		mu.Unlock()
+
+		for key, value := range header {
+			w.Header().Set(key, value)
+		}
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		w.WriteHeader(statuses[i])
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), attempts...)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		header       map[string]string
+		wantAttempts int
+		wantStatus   int
+	}{
+		{"success", http.MethodGet, []int{200}, nil, 1, 200},
+		{"get server error", http.MethodGet, []int{500, 502, 200}, nil, 3, 200},
+		{"get gives up", http.MethodGet, []int{500}, nil, 4, 500},
+		{"get not found", http.MethodGet, []int{404}, nil, 1, 404},
+		{"post server error", http.MethodPost, []int{500}, nil, 1, 500},
+		{"post unavailable", http.MethodPost, []int{503, 200}, nil, 2, 200},
+		{"post too many requests", http.MethodPost, []int{429, 200}, map[string]string{"Retry-After": "0"}, 2, 200},
+		{"patch server error", http.MethodPatch, []int{500, 200}, nil, 2, 200},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, attempts := newTestRetryServer(t, test.statuses, test.header)
+			client := NewClient(&Config{HTTP: httpConfig{RetryDelayMilliseconds: 1}}, nil)
+
+			resp, err := client.do(test.method, server.URL, []byte("{}"), "application/json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != test.wantStatus {
+				t.Errorf("status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+			if got := len(attempts()); got != test.wantAttempts {
+				t.Errorf("%d attempts, want %d", got, test.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestClientRetryBackoff(t *testing.T) {
+	server, attempts := newTestRetryServer(t, []int{500}, nil)
+	client := NewClient(&Config{HTTP: httpConfig{RetryDelayMilliseconds: 20}}, nil)
+
+	resp, err := client.do(http.MethodGet, server.URL, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	times := attempts()
+	if len(times) != 4 {
+		t.Fatalf("%d attempts, want 4", len(times))
+	}
+	want := 20 * time.Millisecond
+	for i := 1; i < len(times); i++ {
+		if delay := times[i].Sub(times[i-1]); delay < want {
+			t.Errorf("retry %d after %v, want at least %v", i, delay, want)
+		}
+		want *= 2
+	}
+}
+
+func TestClientRetryMaxDelay(t *testing.T) {
+	server, attempts := newTestRetryServer(t, []int{429, 200}, map[string]string{"Retry-After": "3600"})
+	client := NewClient(&Config{HTTP: httpConfig{MaxRetryDelaySeconds: 1}}, nil)
+
+	start := time.Now()
+	resp, err := client.do(http.MethodGet, server.URL, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || len(attempts()) != 2 {
+		t.Errorf("status %d after %d attempts, want 200 after 2", resp.StatusCode, len(attempts()))
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 2*time.Second {
+		t.Errorf("retried after %v, want the max delay of 1s", elapsed)
+	}
+}
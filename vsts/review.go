@@ -1,46 +1,77 @@
 package vsts
 
 import (
+	"fmt"
 	"log"
 	"strings"
 )
 
-var config *Config
+// Review runs all enabled reviewers and votes on the result
+func (c *Client) Review(pr *PullRequest) error {
+	sourceVersion, targetVersion := getSourceVersion(pr), getTargetVersion(pr)
+	log.Printf("reviewing PR %v %s %s against %s %s\n", pr.Resource.PullRequestID, sourceVersion.versionType, sourceVersion.version, targetVersion.versionType, targetVersion.version)
 
-func init() {
-	var err error
-	config, err = GetConfig()
-	if err != nil {
-		log.Fatal(err)
-	}
-}
-
-// Review do multiple reviews
-func Review(pr *PullRequest) error {
-	diffs, err := getDiffsBetweenBranches(getBranchNameFromRefName(pr.Resource.TargetRefName), getBranchNameFromRefName(pr.Resource.SourceRefName))
+	diffs, err := c.getDiffs(targetVersion, sourceVersion)
 	if err != nil {
 		return err
 	}
 
-	ir := imageReview{diffs, pr}
-	imagePass, err := ir.review()
-	if err != nil {
-		return err
+	ctx := newReviewContext(c, pr, diffs)
+
+	var state *reviewState
+	if c.config.Incremental.Enabled {
+		state, err = c.startIncrementalReview(ctx)
+		if err != nil {
+			return err
+		}
 	}
 
-	cgr := changeGroupReview{diffs, pr}
-	changeGroupPass, err := cgr.review()
-	if err != nil {
-		return err
+	severity := severityPass
+	var summaries []checkSummary
+	for _, reviewer := range enabledReviewers(c.config) {
+		log.Printf("running check %s: %s\n", reviewer.Name(), reviewer.Description())
+		err := c.setPullRequestStatus(pr, reviewer.Name(), statusStatePending, reviewer.Description())
+		if err != nil {
+			return err
+		}
+
+		result, err := reviewer.Review(ctx)
+		if err == nil {
+			err = ctx.reconcileThreads(reviewer, result)
+		}
+		if err != nil {
+			if statusErr := c.setPullRequestStatus(pr, reviewer.Name(), statusStateError, err.Error()); statusErr != nil {
+				log.Printf("failed to set status of check %s: %v\n", reviewer.Name(), statusErr)
+			}
+			return fmt.Errorf("check %s: %v", reviewer.Name(), err)
+		}
+		if incremental, ok := reviewer.(IncrementalReviewer); ok && incremental.Incremental() {
+			ctx.keepPreviousFindings(reviewer.Name(), result)
+		}
+		if state != nil {
+			state.Findings[reviewer.Name()] = result.Findings
+		}
+
+		statusState, description := getResultStatus(result)
+		err = c.setPullRequestStatus(pr, reviewer.Name(), statusState, description)
+		if err != nil {
+			return err
+		}
+
+		for _, finding := range result.Findings {
+			log.Printf("check %s %s on '%s': %s\n", reviewer.Name(), finding.Severity, finding.FilePath, finding.Message)
+		}
+
+		severity = maxSeverity(severity, result.Severity())
+		summaries = append(summaries, checkSummary{reviewer, result})
 	}
 
-	ser := storageEntitiesReview{diffs, pr}
-	storageEntitiesPass, err := ser.review()
+	err = c.updateSummary(pr, summaries, severity, state)
 	if err != nil {
 		return err
 	}
 
-	err = vote(pr, imagePass && changeGroupPass && storageEntitiesPass)
+	err = c.vote(pr, severity)
 	if err != nil {
 		return err
 	}
@@ -48,57 +79,72 @@ func Review(pr *PullRequest) error {
 	return nil
 }
 
-func vote(pr *PullRequest, pass bool) error {
-	if pass {
+func (c *Client) vote(pr *PullRequest, severity Severity) error {
+	vote, err := c.config.VotePolicy.getVote(severity)
+	if err != nil {
+		return err
+	}
+
+	if severity == severityPass {
 		log.Printf("All check passed for PR: %v\n", pr.Resource.PullRequestID)
+	}
+
+	currentVote := VoteNone
+	for _, reviewer := range pr.Resource.Reviewers {
+		if strings.EqualFold(reviewer.ID, c.config.UserID) {
+			currentVote = reviewer.Vote
+			break
+		}
+	}
+
+	if vote == currentVote {
+		log.Printf("Already voted %v on PR %v\n", vote, pr.Resource.PullRequestID)
+		return nil
+	}
 
-		c, err := containsHumanComments(pr)
+	if vote > currentVote && !c.config.VotePolicy.VoteWithHumanComments {
+		humanCommented, err := c.containsHumanComments(pr)
 		if err != nil {
-			return nil
+			return err
 		}
-		if c {
+		if humanCommented {
 			log.Printf("PR contains human comments, skip voting.\n")
 			return nil
 		}
-		for _, reviewer := range pr.Resource.Reviewers {
-			if strings.EqualFold(reviewer.ID, config.UserID) {
-				if reviewer.Vote < 0 {
-					// reset
-					err := votePullRequest(pr.Resource.PullRequestID, 0)
-					if err != nil {
-						return err
-					}
-				}
-				break
-			}
-		}
-	} else {
-		// wait
-		err := votePullRequest(pr.Resource.PullRequestID, -5)
-		if err != nil {
-			return err
-		}
 	}
 
-	return nil
+	return c.votePullRequest(pr.Resource.PullRequestID, vote)
 }
 
-func containsHumanComments(pr *PullRequest) (bool, error) {
-	commentThreads, err := getCommentThreads(pr.Resource.PullRequestID)
+func (c *Client) containsHumanComments(pr *PullRequest) (bool, error) {
+	commentThreads, err := c.getCommentThreads(pr.Resource.PullRequestID)
 	if err != nil {
 		return false, err
 	}
 
 	for _, thread := range commentThreads.Value {
-		if !thread.IsDeleted {
-			for _, comment := range thread.Comments {
-				if !comment.IsDeleted &&
-					!strings.EqualFold(comment.CommentType, "system") &&
-					strings.EqualFold(comment.Author.ID, config.UserID) &&
-					!strings.HasPrefix(comment.Content, "[BOT_") {
-					log.Printf("Found human comment: %+v\n", comment)
-					return true, nil
-				}
+		if thread.IsDeleted {
+			continue
+		}
+
+		// the bot account is shared with humans, only the first comment and the recorded replies of bot threads are the bot's own
+		botComments := make(map[int]bool)
+		_, marked := getThreadMarker(thread)
+		_, legacy := c.getLegacyCommentPrefix(thread)
+		if (marked && c.isBotAuthor(thread)) || legacy {
+			botComments[1] = true
+			for _, id := range c.getBotReplyIDs(thread) {
+				botComments[id] = true
+			}
+		}
+
+		for _, comment := range thread.Comments {
+			if !comment.IsDeleted &&
+				!strings.EqualFold(comment.CommentType, "system") &&
+				strings.EqualFold(comment.Author.ID, c.config.UserID) &&
+				!botComments[comment.ID] {
+				log.Printf("Found human comment: %+v\n", comment)
+				return true, nil
 			}
 		}
 	}
@@ -5,102 +5,161 @@ import (
 	"log"
 	"sort"
 	"strings"
-	"time"
 )
 
-type changeGroupReview struct {
-	diffs       *diffs
-	pullRequest *PullRequest
+type changeGroupReview struct{}
+
+func (r *changeGroupReview) Name() string {
+	return "changeGroup"
+}
+
+func (r *changeGroupReview) Description() string {
+	return "files in a change group are updated together"
 }
 
 func (r *changeGroupReview) getBotCommentPrefix() string {
 	return "[BOT_Group]\n"
 }
 
-func (r *changeGroupReview) getBotCommentSuffix() string {
-	return "\n*This comment was added by bot, please let me know if you have any suggestion!*"
+// isDirectional reports whether the group requires files only when other files change
+func (g changeGroup) isDirectional() bool {
+	return len(g.When) > 0
 }
 
-func (r *changeGroupReview) getFailedSign() string {
-	signs := []string{":exclamation:", ":warning:", ":scream:", ":broken_heart:", ":no_entry:", ":bangbang:"}
-	return signs[time.Now().Minute()%len(signs)]
+func (g changeGroup) validate() error {
+	if g.isDirectional() {
+		if len(g.Files) > 0 || len(g.Require) == 0 {
+			return fmt.Errorf("change group with 'when' %+v needs 'require' and no 'files'", g.When)
+		}
+	} else if len(g.Require) > 0 {
+		return fmt.Errorf("change group with 'require' %+v needs 'when'", g.Require)
+	}
+	return nil
 }
 
-func (r *changeGroupReview) getCommentContent(group changeGroup) (string, string) {
-	sort.Strings(group)
-	essentialMessage := fmt.Sprintf("These files are usually updated together: **%+v**, please double check.", group)
-	return essentialMessage, fmt.Sprintf(
-		"%s%s %s\n%s",
-		r.getBotCommentPrefix(),
-		r.getFailedSign(),
-		essentialMessage,
-		r.getBotCommentSuffix())
+// check returns the changed files triggering the group and the patterns
+// missing a changed file, the group is violated when both are not empty
+func (g changeGroup) check(changedPaths []string) ([]string, []string) {
+	var triggered []string
+	var missing []string
+	if g.isDirectional() {
+		required := false
+		for _, changedPath := range changedPaths {
+			// Files triggering the group do not satisfy it, e.g. when requiring all locales.
+			if matchAnyGlob(g.When, changedPath) {
+				triggered = append(triggered, changedPath)
+			} else if matchAnyGlob(g.Require, changedPath) {
+				required = true
+			}
+		}
+		if !required {
+			missing = g.Require
+		}
+		return triggered, missing
+	}
+
+	for _, changedPath := range changedPaths {
+		if matchAnyGlob(g.Files, changedPath) {
+			triggered = append(triggered, changedPath)
+		}
+	}
+	for _, pattern := range g.Files {
+		matched := false
+		for _, changedPath := range triggered {
+			if matchGlob(pattern, changedPath) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			missing = append(missing, pattern)
+		}
+	}
+	return triggered, missing
 }
 
-func (r *changeGroupReview) review() (bool, error) {
+func (r *changeGroupReview) getCommentContent(ctx *ReviewContext, group changeGroup, missing []string) (string, string, error) {
+	templateName := "changeGroup"
+	data := CommentData{Missing: missing, Message: group.Message}
+	essentialMessage := group.Message
+	if group.isDirectional() {
+		templateName = "changeGroup.require"
+		data.Files = group.When
+		if len(essentialMessage) == 0 {
+			essentialMessage = fmt.Sprintf("Files matching **%s** changed, please also update **%s**.", strings.Join(group.When, ", "), strings.Join(missing, " or "))
+		}
+	} else {
+		files := append([]string{}, group.Files...)
+		sort.Strings(files)
+		data.Files = files
+		if len(essentialMessage) == 0 {
+			essentialMessage = fmt.Sprintf("These files are usually updated together: **%+v**, please double check.", files)
+		}
+	}
+
+	body, err := ctx.renderComment(templateName, r.Name(), data)
+	if err != nil {
+		return "", "", err
+	}
+	return essentialMessage, body, nil
+}
+
+// changeGroupViolation is a change group violated by a changed file
+type changeGroupViolation struct {
+	group   changeGroup
+	missing []string
+}
+
+func (r *changeGroupReview) Review(ctx *ReviewContext) (*Result, error) {
+	config := ctx.Client.config
 	log.Println("change group check started.")
 
-	changedItemMap := make(map[string]bool)
-	for _, change := range r.diffs.Changes {
-		changedItemMap[change.Item.Path] = true
+	var changedPaths []string
+	for _, change := range ctx.diffs.Changes {
+		if !change.Item.IsFolder {
+			changedPaths = append(changedPaths, change.Item.Path)
+		}
 	}
 
-	missingGroupMap := make(map[string]changeGroup)
+	// only comment the first violated group per file.
+	missingGroupMap := make(map[string]changeGroupViolation)
 	for _, group := range config.ChangeGroups {
-		list := []string{}
-		for _, item := range group {
-			if _, ok := changedItemMap[item]; ok {
-				list = append(list, item)
-			}
+		triggered, missing := group.check(changedPaths)
+		if len(triggered) == 0 || len(missing) == 0 {
+			continue
 		}
-
-		if len(list) > 0 && len(list) < len(group) {
-			for _, item := range list {
-				missingGroupMap[item] = group
+		for _, filePath := range triggered {
+			if _, ok := missingGroupMap[filePath]; !ok {
+				missingGroupMap[filePath] = changeGroupViolation{group, missing}
 			}
 		}
 	}
 
 	if len(missingGroupMap) == 0 {
 		log.Printf("change group check passed.\n")
-		return true, nil
+		return &Result{}, nil
 	}
 
 	log.Printf("change group failed: %+v\n", missingGroupMap)
 
-	commentThreads, err := getCommentThreads(r.pullRequest.Resource.PullRequestID)
-	if err != nil {
-		return false, err
+	result := &Result{}
+	var filePaths []string
+	for filePath := range missingGroupMap {
+		filePaths = append(filePaths, filePath)
 	}
+	sort.Strings(filePaths)
 
-	for filePath, missingGroup := range missingGroupMap {
-		commentThread := commentThread{}
-		for _, thread := range commentThreads.Value {
-			if !thread.IsDeleted && strings.EqualFold(thread.ThreadContext.FilePath, filePath) {
-				for _, comment := range thread.Comments {
-					if comment.ID == 1 && comment.Author.ID == config.UserID && strings.HasPrefix(comment.Content, r.getBotCommentPrefix()) {
-						commentThread = thread
-						break
-					}
-				}
-			}
-		}
-
-		_, commentContent := r.getCommentContent(missingGroup)
-
-		// only add comment once per file.
-		if commentThread.Status == "" {
-			// create thread
-			err := createCommentThread(r.pullRequest.Resource.PullRequestID, filePath, 1, commentContent)
-			if err != nil {
-				return false, err
-			}
-		} else {
-			log.Printf("Already commented on file %s\n", filePath)
+	for _, filePath := range filePaths {
+		missingGroup := missingGroupMap[filePath]
+		essentialMessage, commentBody, err := r.getCommentContent(ctx, missingGroup.group, missingGroup.missing)
+		if err != nil {
+			return nil, err
 		}
+		result.Findings = append(result.Findings, Finding{FilePath: filePath, Severity: SeverityWarning, Message: essentialMessage})
+		result.Comments = append(result.Comments, FileComment{FilePath: filePath, AtChanges: true, Content: commentBody})
 	}
 
 	log.Println("change group completed.")
 
-	return true, nil
+	return result, nil
 }
@@ -0,0 +1,90 @@
+package vsts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChangeGroupCheck(t *testing.T) {
+	var groups []changeGroup
+	err := json.Unmarshal([]byte(`[
+		["/a.txt", "/b.txt"],
+		{"files": ["/src/**/Resources.resx", "/src/**/Resources.*.resx"]},
+		{"when": ["/schema/**"], "require": ["/docs/schema.md", "/CHANGELOG.md"], "message": "Document schema changes."}
+	]`), &groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups[0].Files[1] != "/b.txt" || groups[2].Message != "Document schema changes." {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	tests := []struct {
+		name          string
+		group         changeGroup
+		changed       []string
+		wantTriggered []string
+		wantMissing   []string
+	}{
+		{"untouched", groups[0], []string{"/c.txt"}, nil, []string{"/a.txt", "/b.txt"}},
+		{"all updated", groups[0], []string{"/a.txt", "/b.txt"}, []string{"/a.txt", "/b.txt"}, nil},
+		{"one missing", groups[0], []string{"/a.txt"}, []string{"/a.txt"}, []string{"/b.txt"}},
+		{"glob missing", groups[1], []string{"/src/ui/Resources.resx"}, []string{"/src/ui/Resources.resx"}, []string{"/src/**/Resources.*.resx"}},
+		{"glob updated", groups[1], []string{"/src/ui/Resources.resx", "/src/ui/Resources.de.resx"}, []string{"/src/ui/Resources.resx", "/src/ui/Resources.de.resx"}, nil},
+		{"required missing", groups[2], []string{"/schema/v1.json"}, []string{"/schema/v1.json"}, []string{"/docs/schema.md", "/CHANGELOG.md"}},
+		{"one required updated", groups[2], []string{"/schema/v1.json", "/CHANGELOG.md"}, []string{"/schema/v1.json"}, nil},
+		{"only required updated", groups[2], []string{"/docs/schema.md"}, nil, nil},
+	}
+
+	for _, test := range tests {
+		triggered, missing := test.group.check(test.changed)
+		if !reflect.DeepEqual(triggered, test.wantTriggered) || !reflect.DeepEqual(missing, test.wantMissing) {
+			t.Errorf("%s: got %v %v, want %v %v", test.name, triggered, missing, test.wantTriggered, test.wantMissing)
+		}
+	}
+}
+
+func TestChangeGroupValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   changeGroup
+		wantErr bool
+	}{
+		{"files", changeGroup{Files: []string{"/a.txt", "/b.txt"}}, false},
+		{"directional", changeGroup{When: []string{"/a.txt"}, Require: []string{"/b.txt"}}, false},
+		{"when without require", changeGroup{When: []string{"/a.txt"}}, true},
+		{"when with files", changeGroup{When: []string{"/a.txt"}, Require: []string{"/b.txt"}, Files: []string{"/c.txt"}}, true},
+		{"require without when", changeGroup{Require: []string{"/b.txt"}}, true},
+	}
+
+	for _, test := range tests {
+		if err := test.group.validate(); (err != nil) != test.wantErr {
+			t.Errorf("%s: validate() = %v, want error %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestLoadConfigChangeGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"valid", `{"changeGroups": [["/a.txt", "/b.txt"], {"when": ["/a.txt"], "require": ["/b.txt"]}]}`, false},
+		{"require without when", `{"changeGroups": [["/a.txt", "/b.txt"], {"require": ["/b.txt"]}]}`, true},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := ioutil.WriteFile(path, []byte(test.config), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadConfig(path); (err != nil) != test.wantErr {
+			t.Errorf("%s: LoadConfig() = %v, want error %v", test.name, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+)
+
+type completenessReview struct{}
+
+func (r *completenessReview) Name() string {
+	return "completeness"
+}
+
+func (r *completenessReview) Description() string {
+	return "all changes of the pull request are reviewed"
+}
+
+func (r *completenessReview) getBotCommentPrefix() string {
+	return "[BOT_Completeness]\n"
+}
+
+func (r *completenessReview) Review(ctx *ReviewContext) (*Result, error) {
+	log.Println("completeness check started.")
+
+	result := &Result{}
+	if !ctx.diffs.AllChangesIncluded {
+		result.Findings = append(result.Findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Only the first %d changed files were reviewed, the pull request is too large to review fully.", len(ctx.diffs.Changes)),
+		})
+	}
+
+	data := CommentData{}
+	if len(result.Findings) > 0 {
+		data.Severity = SeverityWarning
+		data.Message = result.Findings[0].Message
+	}
+
+	err := ctx.addGeneralComment(r, result, data)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("completeness check completed.")
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+)
+
+const defaultFreshnessWarning = 50
+
+// mergeStatusConflicts is the merge status of pull requests with conflicts
+const mergeStatusConflicts = "conflicts"
+
+func (c freshnessConfig) getWarning() int {
+	if c.Warning == 0 {
+		return defaultFreshnessWarning
+	}
+	return c.Warning
+}
+
+type freshnessReview struct{}
+
+func (r *freshnessReview) Name() string {
+	return "freshness"
+}
+
+func (r *freshnessReview) Description() string {
+	return "source branch is up to date with target branch and merges without conflicts"
+}
+
+func (r *freshnessReview) getBotCommentPrefix() string {
+	return "[BOT_Freshness]\n"
+}
+
+// getFindings checks commits behind the target branch and the merge status
+func (r *freshnessReview) getFindings(ctx *ReviewContext) []Finding {
+	config := ctx.Client.config.Freshness
+	targetBranch := getBranchNameFromRefName(ctx.PullRequest.Resource.TargetRefName)
+	behind := ctx.diffs.BehindCount
+
+	var findings []Finding
+	severity, threshold := severityPass, 0
+	if config.Error > 0 && behind > config.Error {
+		severity, threshold = SeverityError, config.Error
+	} else if warning := config.getWarning(); warning > 0 && behind > warning {
+		severity, threshold = SeverityWarning, warning
+	}
+	if severity != severityPass {
+		findings = append(findings, Finding{
+			Severity: severity,
+			Message:  fmt.Sprintf("The source branch is %d commits behind `%s`, more than %d, please merge or rebase.", behind, targetBranch, threshold),
+		})
+	}
+
+	if ctx.PullRequest.Resource.MergeStatus == mergeStatusConflicts {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("The source branch has merge conflicts with `%s`.", targetBranch),
+		})
+	}
+
+	return findings
+}
+
+func (r *freshnessReview) Review(ctx *ReviewContext) (*Result, error) {
+	log.Println("freshness check started.")
+	log.Printf("source branch is %d commits ahead and %d commits behind, merge status: %s\n", ctx.diffs.AheadCount, ctx.diffs.BehindCount, ctx.PullRequest.Resource.MergeStatus)
+
+	result := &Result{Findings: r.getFindings(ctx)}
+
+	var problems []string
+	for _, finding := range result.Findings {
+		problems = append(problems, finding.Message)
+	}
+
+	err := ctx.addGeneralComment(r, result, CommentData{Problems: problems, Severity: result.Severity()})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("freshness check completed.")
+	return result, nil
+}
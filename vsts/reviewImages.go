@@ -6,66 +6,52 @@ import (
 	"net/http"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/wenwu449/vsts-pr/ext"
 )
 
-type imageReview struct {
-	diffs       *diffs
-	pullRequest *PullRequest
-}
-
-func (r *imageReview) getBotCommentPrefix() string {
-	return "[BOT_Image]\n"
-}
+type imageReview struct{}
 
-func (r *imageReview) getBotCommentSuffix() string {
-	return "\n*This comment was added by bot, please let me know if you have any suggestion!*"
+func (r *imageReview) Name() string {
+	return "image"
 }
 
-func (r *imageReview) getFailedSign() string {
-	signs := []string{":exclamation:", ":warning:", ":scream:", ":broken_heart:", ":no_entry:", ":bangbang:"}
-	return signs[time.Now().Minute()%len(signs)]
+func (r *imageReview) Description() string {
+	return "image lists include all images deployed on service endpoints"
 }
 
-func (r *imageReview) getPassedSign() string {
-	signs := []string{":heavy_check_mark:", ":thumbsup:", ":white_check_mark:", ":ballot_box_with_check:", ":clap:", ":smiley:"}
-	return signs[time.Now().Minute()%len(signs)]
+// Incremental is false, findings also depend on the images currently deployed on the endpoints
+func (r *imageReview) Incremental() bool {
+	return false
 }
 
-func (r *imageReview) getPassedWord() string {
-	words := []string{"Verified!", "Awesome!", "Clear!", "Great Job!"}
-	return words[time.Now().Minute()%len(words)]
+func (r *imageReview) getBotCommentPrefix() string {
+	return "[BOT_Image]\n"
 }
 
-func (r *imageReview) getCommentContent(missingImages []string) (string, string) {
-	essentialMessage := "All images listed on [acihealth](http://acihealth.azurewebsites.net/#cloudshell) are included in this list."
-	if len(missingImages) == 0 {
-		return essentialMessage, fmt.Sprintf(
-			"%s%s %s %s\n%s",
-			r.getBotCommentPrefix(),
-			r.getPassedSign(),
-			r.getPassedWord(),
-			essentialMessage,
-			r.getBotCommentSuffix())
+func (r *imageReview) getCommentContent(ctx *ReviewContext, configPath string, missingImages []string) (string, string, error) {
+	templateName := "image.passed"
+	essentialMessage := "All images are included in this list."
+	if len(missingImages) > 0 {
+		sort.Strings(missingImages)
+		templateName = "image.failed"
+		essentialMessage = fmt.Sprintf("Following images should be included: %+v", missingImages)
 	}
-	sort.Strings(missingImages)
-	essentialMessage = fmt.Sprintf("Following images should be included:**%+v**", missingImages)
-	return essentialMessage, fmt.Sprintf(
-		"%s%s %s\nYou can get image list from [acihealth](http://acihealth.azurewebsites.net/#cloudshell).\n%s",
-		r.getBotCommentPrefix(),
-		r.getFailedSign(),
-		essentialMessage,
-		r.getBotCommentSuffix())
+
+	body, err := ctx.renderComment(templateName, r.Name(), CommentData{
+		Files:   []string{configPath},
+		Missing: missingImages,
+	})
+	return essentialMessage, body, err
 }
 
-func (r *imageReview) review() (bool, error) {
+func (r *imageReview) Review(ctx *ReviewContext) (*Result, error) {
+	config := ctx.Client.config
 	log.Println("image check started.")
 
 	var changedImageConfigs []imageConfig
 	for _, imageConfig := range config.ImageConfigs {
-		for _, change := range r.diffs.Changes {
+		for _, change := range ctx.Changes() {
 			if strings.EqualFold(imageConfig.ConfigPath, change.Item.Path) {
 				changedImageConfigs = append(changedImageConfigs, imageConfig)
 				break
@@ -75,7 +61,7 @@ func (r *imageReview) review() (bool, error) {
 
 	if len(changedImageConfigs) == 0 {
 		log.Println("No change in image config")
-		return true, nil
+		return &Result{}, nil
 	}
 
 	imageDistinct := make(map[string]map[string]struct{})
@@ -104,9 +90,9 @@ func (r *imageReview) review() (bool, error) {
 	for _, imageConfig := range changedImageConfigs {
 		images := []string{}
 		imageList := imageList{}
-		err := getBranchItemContent(getBranchNameFromRefName(r.pullRequest.Resource.SourceRefName), imageConfig.ConfigPath, &imageList)
+		err := ctx.Client.getItemContent(ctx.sourceVersion, imageConfig.ConfigPath, &imageList)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
 
 		log.Printf("Checking: %s\n", imageConfig.ConfigPath)
@@ -151,58 +137,31 @@ func (r *imageReview) review() (bool, error) {
 		log.Printf("image check failed: %+v\n", missingImagesMap)
 	}
 
-	commentThreads, err := getCommentThreads(r.pullRequest.Resource.PullRequestID)
-	if err != nil {
-		return false, err
-	}
-
+	result := &Result{}
 	for _, imageConfig := range changedImageConfigs {
 		missingImages, ok := missingImagesMap[imageConfig.ConfigPath]
 		if !ok {
 			missingImages = []string{}
 		}
 
-		commentThread := commentThread{}
-		for _, thread := range commentThreads.Value {
-			if !thread.IsDeleted && strings.EqualFold(thread.ThreadContext.FilePath, imageConfig.ConfigPath) {
-				for _, comment := range thread.Comments {
-					if comment.ID == 1 && comment.Author.ID == config.UserID && strings.HasPrefix(comment.Content, r.getBotCommentPrefix()) {
-						commentThread = thread
-						break
-					}
-				}
-			}
-		}
-
-		essentialMessage, commentContent := r.getCommentContent(missingImages)
-
-		status := 1
-		if len(missingImages) == 0 {
-			status = 2
+		essentialMessage, commentBody, err := r.getCommentContent(ctx, imageConfig.ConfigPath, missingImages)
+		if err != nil {
+			return nil, err
 		}
-
-		if commentThread.Status == "" {
-			// create thread
-			err := createCommentThread(r.pullRequest.Resource.PullRequestID, imageConfig.ConfigPath, status, commentContent)
-			if err != nil {
-				return false, err
-			}
-		} else {
-			// add comment
-			err := addComment(r.pullRequest.Resource.PullRequestID, commentThread, essentialMessage, commentContent)
-			if err != nil {
-				return false, err
-			}
-			// set thread active
-			err = setCommentThreadStatus(r.pullRequest.Resource.PullRequestID, commentThread, status)
-			if err != nil {
-				return false, err
-			}
+		// passed image lists are commented as resolved threads
+		result.Comments = append(result.Comments, FileComment{
+			FilePath:  imageConfig.ConfigPath,
+			AtChanges: true,
+			Content:   commentBody,
+			Passed:    len(missingImages) == 0,
+		})
+		if len(missingImages) > 0 {
+			result.Findings = append(result.Findings, Finding{FilePath: imageConfig.ConfigPath, Severity: SeverityError, Message: essentialMessage})
 		}
 	}
 
 	log.Println("image check completed.")
 
 	// review result
-	return len(missingImagesMap) == 0, nil
+	return result, nil
 }
@@ -0,0 +1,105 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+type metadataReview struct{}
+
+func (r *metadataReview) Name() string {
+	return "metadata"
+}
+
+func (r *metadataReview) Description() string {
+	return "pull request title and description follow the rules"
+}
+
+func (r *metadataReview) getBotCommentPrefix() string {
+	return "[BOT_Metadata]\n"
+}
+
+func (c metadataConfig) getSeverity() (Severity, error) {
+	switch c.Severity {
+	case "":
+		return SeverityError, nil
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return c.Severity, nil
+	default:
+		return "", fmt.Errorf("invalid metadata severity '%s'", c.Severity)
+	}
+}
+
+// getProblems returns the failing rules of title and description
+func (r *metadataReview) getProblems(config metadataConfig, title string, description string) ([]string, error) {
+	var problems []string
+
+	if len(config.TitlePattern) > 0 {
+		titlePattern, err := regexp.Compile(config.TitlePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata title pattern: %v", err)
+		}
+		if !titlePattern.MatchString(title) {
+			problems = append(problems, fmt.Sprintf("Title must match `%s`.", config.TitlePattern))
+		}
+	}
+
+	description = strings.TrimSpace(description)
+	if len([]rune(description)) < config.MinDescriptionLength {
+		problems = append(problems, fmt.Sprintf("Description must have at least %d characters.", config.MinDescriptionLength))
+	}
+
+	lines := splitLines(description)
+	for _, section := range config.RequiredSections {
+		found := false
+		for _, line := range lines {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), strings.ToLower(section)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("Description must contain `%s`.", section))
+		}
+	}
+
+	for _, text := range config.BannedText {
+		if strings.Contains(strings.ToLower(description), strings.ToLower(text)) {
+			problems = append(problems, fmt.Sprintf("Description must not contain `%s`, please replace the placeholder.", text))
+		}
+	}
+
+	return problems, nil
+}
+
+func (r *metadataReview) Review(ctx *ReviewContext) (*Result, error) {
+	config := ctx.Client.config
+	log.Println("metadata check started.")
+
+	severity, err := config.Metadata.getSeverity()
+	if err != nil {
+		return nil, err
+	}
+
+	problems, err := r.getProblems(config.Metadata, ctx.PullRequest.Resource.Title, ctx.PullRequest.Resource.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("metadata check problems: %+v\n", problems)
+
+	result := &Result{}
+	for _, problem := range problems {
+		result.Findings = append(result.Findings, Finding{Severity: severity, Message: problem})
+	}
+
+	err = ctx.addGeneralComment(r, result, CommentData{Problems: problems})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("metadata check completed.")
+	return result, nil
+}
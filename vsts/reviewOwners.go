@@ -0,0 +1,105 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+type ownersReview struct{}
+
+func (r *ownersReview) Name() string {
+	return "owners"
+}
+
+func (r *ownersReview) Description() string {
+	return "owners of changed files are reviewers"
+}
+
+func (r *ownersReview) Review(ctx *ReviewContext) (*Result, error) {
+	config := ctx.Client.config
+	log.Println("owners check started.")
+
+	ownersPath := config.Owners.Path
+	if len(ownersPath) == 0 {
+		ownersPath = defaultOwnersPath
+	}
+
+	content, found, err := ctx.TargetFile(ownersPath)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Printf("No ownership file %s in target branch\n", ownersPath)
+		return &Result{}, nil
+	}
+	rules := parseOwners(content)
+
+	ownerMap := make(map[string]bool)
+	unmappedMap := make(map[string]bool)
+	for _, change := range ctx.diffs.Changes {
+		if change.Item.IsFolder {
+			continue
+		}
+		for _, owner := range getOwners(rules, change.Item.Path) {
+			if id, ok := config.Owners.Identities[owner]; ok {
+				owner = id
+			} else if strings.Contains(owner, "@") {
+				// team names and emails are no reviewer IDs
+				unmappedMap[owner] = true
+				continue
+			}
+			ownerMap[strings.ToLower(owner)] = true
+		}
+	}
+
+	result := &Result{}
+	var unmapped []string
+	for owner := range unmappedMap {
+		unmapped = append(unmapped, owner)
+	}
+	sort.Strings(unmapped)
+	for _, owner := range unmapped {
+		log.Printf("owner %s is not mapped in owners.identities\n", owner)
+		result.Findings = append(result.Findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Owner %s of changed files has no reviewer ID in owners.identities and was not added.", owner),
+		})
+	}
+
+	// authors do not review their own changes.
+	delete(ownerMap, strings.ToLower(ctx.PullRequest.Resource.CreatedBy.ID))
+	delete(ownerMap, strings.ToLower(config.UserID))
+
+	for _, reviewer := range ctx.PullRequest.Resource.Reviewers {
+		if reviewer.IsRequired || !config.Owners.Required {
+			delete(ownerMap, strings.ToLower(reviewer.ID))
+		}
+	}
+
+	var owners []string
+	for owner := range ownerMap {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	var added []string
+	for _, owner := range owners {
+		err := ctx.Client.addReviewer(ctx.PullRequest.Resource.PullRequestID, owner, config.Owners.Required)
+		if err != nil {
+			// unknown IDs must not stop the review
+			log.Printf("failed to add owner %s as reviewer: %v\n", owner, err)
+			result.Findings = append(result.Findings, Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("Owner %s of changed files could not be added as reviewer: %v", owner, err),
+			})
+			continue
+		}
+		added = append(added, owner)
+	}
+
+	log.Printf("owners check completed, added reviewers: %+v\n", added)
+
+	return result, nil
+}
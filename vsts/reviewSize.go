@@ -0,0 +1,188 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultSizeWarningFiles = 50
+	defaultSizeWarningLines = 1000
+)
+
+// sizeBinaryFiles are globs of files without lines to count
+var sizeBinaryFiles = []string{
+	"**/*.png", "**/*.jpg", "**/*.jpeg", "**/*.gif", "**/*.bmp", "**/*.ico",
+	"**/*.pdf", "**/*.zip", "**/*.gz", "**/*.7z", "**/*.jar", "**/*.nupkg",
+	"**/*.dll", "**/*.exe", "**/*.pdb", "**/*.so", "**/*.dylib",
+	"**/*.snk", "**/*.pfx", "**/*.ttf", "**/*.otf", "**/*.woff", "**/*.woff2",
+}
+
+// PullRequestSize is the size of the counted changes of a pull request
+type PullRequestSize struct {
+	Files   int
+	Added   int
+	Removed int
+	// Directories break the size down per top-level directory, "/" for files in the root
+	Directories []DirectorySize
+}
+
+// DirectorySize is the size of the changes below a top-level directory
+type DirectorySize struct {
+	Path    string
+	Files   int
+	Added   int
+	Removed int
+}
+
+func (c sizeConfig) getWarning() sizeThresholds {
+	warning := c.Warning
+	if warning.Files == 0 {
+		warning.Files = defaultSizeWarningFiles
+	}
+	if warning.Lines == 0 {
+		warning.Lines = defaultSizeWarningLines
+	}
+	return warning
+}
+
+func (c sizeConfig) getExclude() []string {
+	if c.Exclude == nil {
+		return defaultGeneratedFiles
+	}
+	return c.Exclude
+}
+
+// exceeds describes the first threshold the size is above, empty if there is none
+func (t sizeThresholds) exceeds(size *PullRequestSize, name string) string {
+	if t.Files > 0 && size.Files > t.Files {
+		return fmt.Sprintf("above the %s threshold of %d files", name, t.Files)
+	}
+	if t.Lines > 0 && size.Added+size.Removed > t.Lines {
+		return fmt.Sprintf("above the %s threshold of %d lines", name, t.Lines)
+	}
+	return ""
+}
+
+// hasCountedLines reports whether the lines of a change are counted,
+// binary, deleted and only renamed files are counted without lines to save fetching their contents
+func hasCountedLines(change Change) bool {
+	if matchAnyGlob(sizeBinaryFiles, change.Item.Path) {
+		return false
+	}
+
+	// change types are combined, e.g. "edit, rename"
+	changeTypes := make(map[string]bool)
+	for _, changeType := range strings.Split(strings.ToLower(change.ChangeType), ",") {
+		changeTypes[strings.TrimSpace(changeType)] = true
+	}
+	return !changeTypes["delete"] && (changeTypes["add"] || changeTypes["edit"])
+}
+
+func getTopLevelDirectory(filePath string) string {
+	filePath = strings.TrimPrefix(filePath, "/")
+	if i := strings.Index(filePath, "/"); i >= 0 {
+		return "/" + filePath[:i]
+	}
+	return "/"
+}
+
+type sizeReview struct{}
+
+func (r *sizeReview) Name() string {
+	return "size"
+}
+
+func (r *sizeReview) Description() string {
+	return "pull requests are small enough to review"
+}
+
+func (r *sizeReview) getBotCommentPrefix() string {
+	return "[BOT_Size]\n"
+}
+
+// getSize counts changed files and lines, excluding folders and excluded files
+func (r *sizeReview) getSize(ctx *ReviewContext) (*PullRequestSize, error) {
+	exclude := ctx.Client.config.Size.getExclude()
+
+	size := &PullRequestSize{}
+	directories := make(map[string]*DirectorySize)
+	for _, change := range ctx.diffs.Changes {
+		if change.Item.IsFolder || matchAnyGlob(exclude, change.Item.Path) {
+			continue
+		}
+
+		path := getTopLevelDirectory(change.Item.Path)
+		directory, ok := directories[path]
+		if !ok {
+			directory = &DirectorySize{Path: path}
+			directories[path] = directory
+		}
+
+		directory.Files++
+		if !hasCountedLines(change) {
+			continue
+		}
+
+		hunks, err := ctx.Hunks(change.Item.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, hunk := range hunks {
+			directory.Added += hunk.RightLines
+			directory.Removed += hunk.LeftLines
+		}
+	}
+
+	for _, directory := range directories {
+		size.Files += directory.Files
+		size.Added += directory.Added
+		size.Removed += directory.Removed
+		size.Directories = append(size.Directories, *directory)
+	}
+	sort.Slice(size.Directories, func(i, j int) bool {
+		return size.Directories[i].Path < size.Directories[j].Path
+	})
+
+	return size, nil
+}
+
+func (r *sizeReview) Review(ctx *ReviewContext) (*Result, error) {
+	config := ctx.Client.config
+	log.Println("size check started.")
+
+	size, err := r.getSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("size: %d files, +%d -%d lines\n", size.Files, size.Added, size.Removed)
+
+	severity, message := severityPass, ""
+	if message = config.Size.Error.exceeds(size, "error"); len(message) > 0 {
+		severity = SeverityError
+	} else if message = config.Size.getWarning().exceeds(size, "warning"); len(message) > 0 {
+		severity = SeverityWarning
+	}
+
+	result := &Result{}
+	if severity != severityPass {
+		result.Findings = append(result.Findings, Finding{
+			Severity: severity,
+			Message:  fmt.Sprintf("%d files with +%d -%d lines, %s", size.Files, size.Added, size.Removed, message),
+		})
+	}
+
+	err = ctx.addGeneralComment(r, result, CommentData{
+		Size:     size,
+		Severity: severity,
+		Message:  message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("size check completed.")
+	return result, nil
+}
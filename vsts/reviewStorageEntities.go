@@ -1,85 +1,122 @@
 package vsts
 
 import (
-	"fmt"
 	"log"
 	"sort"
 	"strings"
 )
 
-type storageEntitiesReview struct {
-	diffs       *diffs
-	pullRequest *PullRequest
+type storageEntitiesReview struct{}
+
+func (r *storageEntitiesReview) Name() string {
+	return "storageEntities"
+}
+
+func (r *storageEntitiesReview) Description() string {
+	return "changes to storage entities keep back compatibility"
+}
+
+func (r *storageEntitiesReview) Incremental() bool {
+	return true
 }
 
 func (r *storageEntitiesReview) getBotCommentPrefix() string {
 	return "[BOT_Entities]\n"
 }
 
-func (r *storageEntitiesReview) getBotCommentSuffix() string {
-	return "\n*This comment was added by bot, please let me know if you have any suggestion!*"
+func (r *storageEntitiesReview) getCommentContent(ctx *ReviewContext, filePath string, changes []entityChange) (string, error) {
+	descriptions := make([]string, 0, len(changes))
+	for _, change := range changes {
+		descriptions = append(descriptions, change.Description)
+	}
+
+	return ctx.renderComment("storageEntities", r.Name(), CommentData{
+		Files:   []string{filePath},
+		Changes: descriptions,
+	})
 }
 
-func (r *storageEntitiesReview) getCommentContent(changedStorageEntityPathes []string) string {
-	return fmt.Sprintf(
-		"%s:warning:\nThe following storage entities were changed:\n**%+v**\nMake sure you are not removing any properties that will break back compatibility.%s",
-		r.getBotCommentPrefix(),
-		changedStorageEntityPathes,
-		r.getBotCommentSuffix())
+// getBreakingChanges compares the C# classes of a changed entity file in the merge base and source
+func (r *storageEntitiesReview) getBreakingChanges(ctx *ReviewContext, change Change) ([]entityChange, error) {
+	baseContent, found, err := ctx.BaseFile(ctx.getBasePath(change.Item.Path))
+	if err != nil || !found {
+		return nil, err
+	}
+
+	sourceContent := ""
+	if change.ChangeType != "delete" {
+		sourceContent, _, err = ctx.SourceFile(change.Item.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return compareEntityClasses(parseEntityClasses(baseContent), parseEntityClasses(sourceContent)), nil
 }
 
-func (r *storageEntitiesReview) review() (bool, error) {
+func (r *storageEntitiesReview) Review(ctx *ReviewContext) (*Result, error) {
+	config := ctx.Client.config
 	log.Println("storage entities check started.")
 
-	var changedStorageEntityPathes []string
-	for _, change := range r.diffs.Changes {
+	var changedStorageEntities []Change
+	for _, change := range ctx.ReviewChanges() {
 		for _, storageEntityPrefix := range config.StorageEntitiesPrefix {
 			// Ignore folders.
 			// Usually add new entities won't break back compatibility, thus ignore.
 			if change.ChangeType != "add" && !change.Item.IsFolder && strings.HasPrefix(change.Item.Path, storageEntityPrefix) {
-				changedStorageEntityPathes = append(changedStorageEntityPathes, change.Item.Path)
+				changedStorageEntities = append(changedStorageEntities, change)
+				break
 			}
 		}
 	}
 
-	sort.Strings(changedStorageEntityPathes)
+	sort.Slice(changedStorageEntities, func(i, j int) bool {
+		return changedStorageEntities[i].Item.Path < changedStorageEntities[j].Item.Path
+	})
+
+	breakingChanges := make(map[string][]entityChange)
+	var breakingPathes []string
+	for _, change := range changedStorageEntities {
+		changes, err := r.getBreakingChanges(ctx, change)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			breakingChanges[change.Item.Path] = changes
+			breakingPathes = append(breakingPathes, change.Item.Path)
+		}
+	}
 
-	if len(changedStorageEntityPathes) == 0 {
+	if len(breakingPathes) == 0 {
 		log.Printf("storage entities check passed.\n")
-		return true, nil
+		return &Result{}, nil
 	}
 
-	log.Printf("storage entities check contains warning for files: %+v\n", changedStorageEntityPathes)
+	log.Printf("storage entities check failed for files: %+v\n", breakingPathes)
 
-	commentThreads, err := getCommentThreads(r.pullRequest.Resource.PullRequestID)
-	if err != nil {
-		return false, err
-	}
+	result := &Result{}
+	for _, filePath := range breakingPathes {
+		changes := breakingChanges[filePath]
+		commentBody, err := r.getCommentContent(ctx, filePath, changes)
+		if err != nil {
+			return nil, err
+		}
 
-	commentThread := commentThread{}
-	for _, thread := range commentThreads.Value {
-		if !thread.IsDeleted && thread.ThreadContext.FilePath == "" {
-			for _, comment := range thread.Comments {
-				if comment.ID == 1 && comment.Author.ID == config.UserID && strings.HasPrefix(comment.Content, r.getBotCommentPrefix()) {
-					commentThread = thread
-					break
-				}
+		// anchor the thread at the first breaking change left in source
+		comment := FileComment{FilePath: filePath, Content: commentBody}
+		for _, change := range changes {
+			if change.Line > 0 {
+				comment.Line = change.Line
+				break
 			}
 		}
-	}
+		result.Comments = append(result.Comments, comment)
 
-	if commentThread.Status == "" {
-		commentContent := r.getCommentContent(changedStorageEntityPathes)
-		err := createCommentThread(r.pullRequest.Resource.PullRequestID, "", 1, commentContent)
-		if err != nil {
-			return false, err
+		for _, change := range changes {
+			result.Findings = append(result.Findings, Finding{FilePath: filePath, Line: change.Line, Severity: SeverityError, Message: change.Description})
 		}
-
-		// Only return false when creating the comment for the first time.
-		return false, nil
 	}
 
-	// As long as the comment exists, just pass.
 	log.Printf("storage entities check completed.\n")
-	return true, nil
+	return result, nil
 }
@@ -0,0 +1,149 @@
+package vsts
+
+import (
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+var defaultTestCompanionRules = []testCompanionRule{
+	{
+		Sources: []string{"**/*.go"},
+		Tests:   []string{"{dir}/{name}_test.go"},
+		Exclude: []string{"**/*_test.go"},
+	},
+	{
+		Sources: []string{"**/*.cs"},
+		Tests:   []string{"**/{name}Tests.cs", "**/{name}Test.cs"},
+		Exclude: []string{"**/*Tests.cs", "**/*Test.cs"},
+	},
+}
+
+func (c testCompanionConfig) getRules() []testCompanionRule {
+	if len(c.Rules) == 0 {
+		return defaultTestCompanionRules
+	}
+	return c.Rules
+}
+
+func (c testCompanionConfig) getExclude() []string {
+	if c.Exclude == nil {
+		return defaultGeneratedFiles
+	}
+	return c.Exclude
+}
+
+// matches reports whether the rule applies to filePath
+func (r testCompanionRule) matches(filePath string) bool {
+	return matchAnyGlob(r.Sources, filePath) && !matchAnyGlob(r.Exclude, filePath)
+}
+
+// getTests returns the globs of test files expected for filePath
+func (r testCompanionRule) getTests(filePath string) []string {
+	ext := path.Ext(filePath)
+	replacer := strings.NewReplacer(
+		"{dir}", escapeGlob(path.Dir(filePath)),
+		"{name}", escapeGlob(strings.TrimSuffix(path.Base(filePath), ext)),
+		"{ext}", escapeGlob(ext))
+
+	tests := make([]string, 0, len(r.Tests))
+	for _, test := range r.Tests {
+		tests = append(tests, replacer.Replace(test))
+	}
+	return tests
+}
+
+type testCompanionReview struct{}
+
+func (r *testCompanionReview) Name() string {
+	return "testCompanion"
+}
+
+func (r *testCompanionReview) Description() string {
+	return "source files are updated together with their tests"
+}
+
+func (r *testCompanionReview) getBotCommentPrefix() string {
+	return "[BOT_Test]\n"
+}
+
+// getMissingTests returns the expected tests of changed source files without any changed test
+func (r *testCompanionReview) getMissingTests(config *Config, changes []Change) map[string][]string {
+	rules := config.TestCompanion.getRules()
+	exclude := config.TestCompanion.getExclude()
+
+	var changedPaths []string
+	for _, change := range changes {
+		if !change.Item.IsFolder {
+			changedPaths = append(changedPaths, change.Item.Path)
+		}
+	}
+
+	missingTests := make(map[string][]string)
+	for _, change := range changes {
+		// Deleted files need no tests.
+		filePath := change.Item.Path
+		if change.Item.IsFolder || change.ChangeType == "delete" || matchAnyGlob(exclude, filePath) {
+			continue
+		}
+
+		for _, rule := range rules {
+			if !rule.matches(filePath) {
+				continue
+			}
+
+			tests := rule.getTests(filePath)
+			found := false
+			for _, changedPath := range changedPaths {
+				if matchAnyGlob(tests, changedPath) {
+					log.Printf("%s has test update: %s\n", filePath, changedPath)
+					found = true
+					break
+				}
+			}
+			if !found {
+				missingTests[filePath] = tests
+			}
+			break
+		}
+	}
+
+	return missingTests
+}
+
+func (r *testCompanionReview) Review(ctx *ReviewContext) (*Result, error) {
+	config := ctx.Client.config
+	log.Println("test companion check started.")
+
+	missingTests := r.getMissingTests(config, ctx.diffs.Changes)
+	if len(missingTests) == 0 {
+		log.Printf("test companion check passed.\n")
+		return &Result{}, nil
+	}
+
+	var sourceFiles []string
+	for filePath := range missingTests {
+		sourceFiles = append(sourceFiles, filePath)
+	}
+	sort.Strings(sourceFiles)
+	log.Printf("test companion check failed: %+v\n", sourceFiles)
+
+	result := &Result{}
+	for _, filePath := range sourceFiles {
+		commentBody, err := ctx.renderComment("testCompanion", r.Name(), CommentData{
+			Files:   []string{filePath},
+			Missing: missingTests[filePath],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Findings = append(result.Findings, Finding{FilePath: filePath, Severity: SeverityWarning, Message: "Please update test."})
+		result.Comments = append(result.Comments, FileComment{FilePath: filePath, AtChanges: true, Content: commentBody})
+	}
+
+	log.Println("test companion check completed.")
+
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var workItemMentionPattern = regexp.MustCompile(`(?i)\bAB#(\d+)`)
+
+// getMentionedWorkItems returns IDs of work items referenced as AB#1234 in title, description or source branch
+func getMentionedWorkItems(pr *PullRequest) []int {
+	text := strings.Join([]string{pr.Resource.Title, pr.Resource.Description, getBranchNameFromRefName(pr.Resource.SourceRefName)}, "\n")
+
+	idMap := make(map[int]bool)
+	var ids []int
+	for _, match := range workItemMentionPattern.FindAllStringSubmatch(text, -1) {
+		id, err := strconv.Atoi(match[1])
+		if err == nil && !idMap[id] {
+			idMap[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, i int) bool {
+	for _, item := range list {
+		if item == i {
+			return true
+		}
+	}
+	return false
+}
+
+type workItemsReview struct{}
+
+func (r *workItemsReview) Name() string {
+	return "workItems"
+}
+
+func (r *workItemsReview) Description() string {
+	return "pull requests are linked to valid work items"
+}
+
+func (r *workItemsReview) getBotCommentPrefix() string {
+	return "[BOT_WorkItems]\n"
+}
+
+// getProblems links mentioned work items if configured and validates the linked ones
+func (r *workItemsReview) getProblems(ctx *ReviewContext) ([]string, error) {
+	config := ctx.Client.config.WorkItems
+	pr := ctx.PullRequest
+
+	linked, err := ctx.Client.getPullRequestWorkItems(pr.Resource.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	if config.AutoLink {
+		for _, id := range getMentionedWorkItems(pr) {
+			if containsInt(linked, id) {
+				continue
+			}
+
+			items, err := ctx.Client.getWorkItems([]int{id})
+			if err != nil {
+				return nil, err
+			}
+			if len(items) == 0 {
+				problems = append(problems, fmt.Sprintf("Work item AB#%d does not exist.", id))
+				continue
+			}
+
+			if err := ctx.Client.linkWorkItem(pr, id); err != nil {
+				return nil, err
+			}
+			linked = append(linked, id)
+		}
+	}
+
+	if len(linked) == 0 {
+		return append(problems, "No work item is linked."), nil
+	}
+
+	items, err := ctx.Client.getWorkItems(linked)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		switch {
+		case len(config.AllowedTypes) > 0 && !containsFold(config.AllowedTypes, item.Fields.WorkItemType):
+			problems = append(problems, fmt.Sprintf("Work item AB#%d has type %s, allowed types are %s.", item.ID, item.Fields.WorkItemType, strings.Join(config.AllowedTypes, ", ")))
+		case containsFold(config.DisallowedStates, item.Fields.State):
+			problems = append(problems, fmt.Sprintf("Work item AB#%d is %s.", item.ID, item.Fields.State))
+		}
+	}
+
+	return problems, nil
+}
+
+func (r *workItemsReview) Review(ctx *ReviewContext) (*Result, error) {
+	log.Println("work items check started.")
+
+	problems, err := r.getProblems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("work items check problems: %+v\n", problems)
+
+	result := &Result{}
+	for _, problem := range problems {
+		result.Findings = append(result.Findings, Finding{Severity: SeverityError, Message: problem})
+	}
+
+	err = ctx.addGeneralComment(r, result, CommentData{Problems: problems})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("work items check completed.")
+	return result, nil
+}
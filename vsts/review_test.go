@@ -0,0 +1,1088 @@
+package vsts
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wenwu449/vsts-pr/vsts/vststest"
+)
+
+const (
+	testPullRequestID = 7
+	testUserID        = "bot-id"
+	testSourceBranch  = "feature"
+)
+
+func newTestClient(t *testing.T) (*vststest.Server, *Client) {
+	server := vststest.NewServer("project", "repo", testUserID)
+	t.Cleanup(server.Close)
+
+	config := &Config{
+		Scheme:       "http",
+		Instance:     server.Host(),
+		Project:      "project",
+		Repo:         "repo",
+		MasterBranch: "master",
+		UserID:       testUserID,
+		HTTP:         httpConfig{MaxRetries: -1},
+	}
+
+	return server, NewClient(config, nil)
+}
+
+func newTestPullRequest() *PullRequest {
+	pr := &PullRequest{ID: "event-id", EventType: "git.pullrequest.updated"}
+	pr.Resource.PullRequestID = testPullRequestID
+	pr.Resource.SourceRefName = "refs/heads/" + testSourceBranch
+	pr.Resource.TargetRefName = "refs/heads/master"
+	return pr
+}
+
+func setTestBotVote(pr *PullRequest, vote int) {
+	pr.Resource.Reviewers = append(pr.Resource.Reviewers, IdentityRefWithVote{ID: testUserID, Vote: vote})
+}
+
+func findTestThread(threads []vststest.Thread, filePath string, prefix string) *vststest.Thread {
+	for i, thread := range threads {
+		if thread.FilePath() == filePath && len(thread.Comments) > 0 && strings.HasPrefix(thread.Comments[0].Content, prefix) {
+			return &threads[i]
+		}
+	}
+	return nil
+}
+
+func TestReviewChangeGroup(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.ChangeGroups = []changeGroup{{Files: []string{"/a.txt", "/b.txt"}}}
+
+	server.SetFile("master", "/a.txt", "a1\na2\na3\n")
+	server.SetFile("master", "/b.txt", "b")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/a.txt", "a1\nchanged\nadded\na3\n")
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	threads := server.Threads(testPullRequestID)
+	if len(threads) != 1 {
+		t.Fatalf("got %d threads, want 1", len(threads))
+	}
+	thread := findTestThread(threads, "/a.txt", "[BOT_Group]")
+	if thread == nil {
+		t.Fatalf("no change group thread on /a.txt: %+v", threads)
+	}
+	if thread.Status != "active" {
+		t.Errorf("thread status %s, want active", thread.Status)
+	}
+	if start, end := thread.ThreadContext.RightFileStart, thread.ThreadContext.RightFileEnd; start.Line != 2 || end.Line != 3 || end.Offset != 6 {
+		t.Errorf("thread at %+v-%+v, want changed lines 2-3", start, end)
+	}
+	if !strings.Contains(thread.Comments[0].Content, "/b.txt") {
+		t.Errorf("comment does not mention /b.txt: %s", thread.Comments[0].Content)
+	}
+	if vote, ok := server.Vote(testPullRequestID, testUserID); ok {
+		t.Errorf("unexpected vote %d", vote)
+	}
+
+	// a second run must not comment twice
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if threads := server.Threads(testPullRequestID); len(threads) != 1 {
+		t.Errorf("got %d threads after second run, want 1", len(threads))
+	}
+}
+
+func TestReviewChangeGroupRequire(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.ChangeGroups = []changeGroup{{
+		When:    []string{"/locales/en/*.json"},
+		Require: []string{"/locales/*/*.json"},
+		Message: "Please update all locales.",
+	}}
+
+	server.SetFile("master", "/locales/en/app.json", "{}")
+	server.SetFile("master", "/locales/de/app.json", "{}")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/locales/en/app.json", `{"a": "A"}`)
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	thread := findTestThread(server.Threads(testPullRequestID), "/locales/en/app.json", "[BOT_Group]")
+	if thread == nil {
+		t.Fatal("no change group thread on /locales/en/app.json")
+	}
+	if !strings.Contains(thread.Comments[0].Content, "Please update all locales.") {
+		t.Errorf("comment without custom message: %s", thread.Comments[0].Content)
+	}
+}
+
+func TestReviewCommentTemplates(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.ChangeGroups = []changeGroup{{Files: []string{"/a.txt", "/b.txt"}}}
+	client.config.Templates = map[string]string{
+		"changeGroup": `{{.Check}} on #{{.PullRequest.Resource.PullRequestID}}: please update {{join .Missing ", "}}`,
+	}
+
+	server.SetFile("master", "/a.txt", "a")
+	server.SetFile("master", "/b.txt", "b")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/a.txt", "changed")
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	thread := findTestThread(server.Threads(testPullRequestID), "/a.txt", "[BOT_Group]")
+	if thread == nil {
+		t.Fatal("no change group thread on /a.txt")
+	}
+	want := fmt.Sprintf("[BOT_Group]\nchangeGroup on #%d: please update /b.txt", testPullRequestID)
+	if got := thread.Comments[0].Content; got != want {
+		t.Errorf("comment %q, want %q", got, want)
+	}
+
+	client.config.Templates["changeGroup"] = "{{.Unknown"
+	if err := client.Review(newTestPullRequest()); err == nil {
+		t.Error("review with invalid template succeeded")
+	}
+}
+
+func TestReviewStorageEntities(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.StorageEntitiesPrefix = []string{"/src/Entities"}
+	client.config.Checks = map[string]bool{"testCompanion": false}
+
+	server.SetFile("master", "/src/Entities/User.cs", "class User\n{\n    public string Name { get; set; }\n}\n")
+	server.SetFile("master", "/src/Entities/Legacy.cs", "class Legacy\n{\n    public string Id { get; set; }\n}\n")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User\n{\n    // full name\n    public string Name { get; set; }\n}\n")
+	server.SetFile(testSourceBranch, "/src/Entities/Legacy.cs", "class Legacy\n{\n    public string Key { get; set; }\n}\n")
+	server.SetFile(testSourceBranch, "/src/Entities/Role.cs", "class Role {}")
+
+	// the general thread of earlier versions listing all changed entities
+	generalThreadID := server.AddThread(testPullRequestID, vststest.Thread{
+		Status:   "active",
+		Comments: []vststest.Comment{{ID: 1, Author: vststest.Author{ID: testUserID}, Content: "[BOT_Entities]\n:warning:\nThe following storage entities were changed", CommentType: "text"}},
+	})
+
+	// a resolved file thread of earlier versions only gets its marker
+	oldThreadID := server.AddThread(testPullRequestID, vststest.Thread{
+		Status:        "fixed",
+		ThreadContext: &vststest.ThreadContext{FilePath: "/src/Entities/Old.cs"},
+		Comments:      []vststest.Comment{{ID: 1, Author: vststest.Author{ID: testUserID}, Content: "[BOT_Entities]\n:x: **/src/Entities/Old.cs** has breaking changes", CommentType: "text"}},
+	})
+
+	pr := newTestPullRequest()
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	threads := server.Threads(testPullRequestID)
+	if len(threads) != 3 {
+		t.Fatalf("got %d threads, want 3: %+v", len(threads), threads)
+	}
+	thread := findTestThread(threads, "/src/Entities/Legacy.cs", "[BOT_Entities]")
+	if thread == nil {
+		t.Fatalf("no storage entities thread on /src/Entities/Legacy.cs: %+v", threads)
+	}
+	if !strings.Contains(thread.Comments[0].Content, "`Legacy.Id` was renamed to `Key`") {
+		t.Errorf("comment does not mention renamed property: %s", thread.Comments[0].Content)
+	}
+	if thread.ThreadContext.RightFileStart == nil || thread.ThreadContext.RightFileStart.Line != 3 {
+		t.Errorf("thread at %+v, want renamed property at line 3", thread.ThreadContext.RightFileStart)
+	}
+	for _, thread := range threads {
+		if thread.ID == generalThreadID && (thread.Status != "fixed" || thread.Properties[markerCheckProperty].Value != "storageEntities") {
+			t.Errorf("general thread %s with marker %v, want fixed and marked", thread.Status, thread.Properties[markerCheckProperty].Value)
+		}
+		if thread.ID == oldThreadID && (thread.Status != "fixed" || thread.Properties[markerKeyProperty].Value != "/src/Entities/Old.cs") {
+			t.Errorf("old file thread %s with key %v, want fixed and marked", thread.Status, thread.Properties[markerKeyProperty].Value)
+		}
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != -5 {
+		t.Errorf("vote %d, want -5", vote)
+	}
+
+	// once the property is restored, the check passes and the vote is reset
+	server.SetFile(testSourceBranch, "/src/Entities/Legacy.cs", "class Legacy\n{\n    public string Id { get; set; }\n    public string Key { get; set; }\n}\n")
+	setTestBotVote(pr, -5)
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != 0 {
+		t.Errorf("vote %d after second run, want 0", vote)
+	}
+}
+
+func TestReviewStorageEntitiesRenamedFile(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.StorageEntitiesPrefix = []string{"/src/Entities"}
+	client.config.Checks = map[string]bool{"testCompanion": false}
+
+	server.SetFile("master", "/src/Entities/Account.cs", "class Account\n{\n    public string Id { get; set; }\n    public long Balance { get; set; }\n}\n")
+	server.CopyBranch("master", testSourceBranch)
+	server.RenameFile(testSourceBranch, "/src/Entities/Account.cs", "/src/Entities/Billing/Account.cs")
+	server.SetFile(testSourceBranch, "/src/Entities/Billing/Account.cs", "class Account\n{\n    public string Id { get; set; }\n}\n")
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the renamed file is compared with its original path in the merge base
+	thread := findTestThread(server.Threads(testPullRequestID), "/src/Entities/Billing/Account.cs", "[BOT_Entities]")
+	if thread == nil {
+		t.Fatalf("no storage entities thread on the renamed file: %+v", server.Threads(testPullRequestID))
+	}
+	if !strings.Contains(thread.Comments[0].Content, "`Account.Balance` was removed") {
+		t.Errorf("comment does not mention removed property: %s", thread.Comments[0].Content)
+	}
+}
+
+func TestReviewPinnedCommits(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.StorageEntitiesPrefix = []string{"/src/Entities"}
+	client.config.Checks = map[string]bool{"testCompanion": false}
+
+	server.SetFile("master", "/src/Entities/User.cs", "class User\n{\n    public string Name { get; set; }\n}\n")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User\n{\n    public string Name { get; set; }\n    public int Age { get; set; }\n}\n")
+
+	pr := newTestPullRequest()
+	pr.Resource.LastMergeSourceCommit.CommitID = server.Commit(testSourceBranch)
+	pr.Resource.LastMergeTargetCommit.CommitID = server.Commit("master")
+
+	// a push after the event is not reviewed
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User\n{\n}\n")
+
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	if thread := findTestThread(server.Threads(testPullRequestID), "/src/Entities/User.cs", "[BOT_Entities]"); thread != nil {
+		t.Errorf("unexpected thread for a push after the event: %s", thread.Comments[0].Content)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != 0 {
+		t.Errorf("vote %d, want 0", vote)
+	}
+}
+
+func TestReviewMergeBase(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.StorageEntitiesPrefix = []string{"/src/Entities"}
+	client.config.Checks = map[string]bool{"testCompanion": false, "size": true}
+	client.config.Size = sizeConfig{Warning: sizeThresholds{Files: 1, Lines: -1}}
+
+	server.SetFile("master", "/src/Entities/User.cs", "class User\n{\n    public string Name { get; set; }\n}\n")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User\n{\n    public string Name { get; set; }\n    public int Age { get; set; }\n}\n")
+
+	// the target branch advances after the source branched off
+	server.SetFile("master", "/src/Entities/User.cs", "class User\n{\n    public string Name { get; set; }\n    public string Email { get; set; }\n}\n")
+	server.SetFile("master", "/docs/new.md", "new\n")
+
+	pr := newTestPullRequest()
+	pr.Resource.LastMergeSourceCommit.CommitID = server.Commit(testSourceBranch)
+	pr.Resource.LastMergeTargetCommit.CommitID = server.Commit("master")
+
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	threads := server.Threads(testPullRequestID)
+	if thread := findTestThread(threads, "/src/Entities/User.cs", "[BOT_Entities]"); thread != nil {
+		t.Errorf("unexpected storage entities thread for a property added to the target: %s", thread.Comments[0].Content)
+	}
+	// only the changed entity counts, not the files added to the target
+	if thread := findTestThread(threads, "", "[BOT_Size]"); thread != nil {
+		t.Errorf("unexpected size thread for files added to the target: %s", thread.Comments[0].Content)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != 0 {
+		t.Errorf("vote %d, want 0", vote)
+	}
+}
+
+func TestReviewTestCompanion(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Checks = map[string]bool{"storageEntities": false}
+
+	server.SetFile("master", "/pkg/a.go", "package pkg")
+	server.SetFile("master", "/pkg/b.go", "package pkg")
+	server.SetFile("master", "/pkg/b_test.go", "package pkg")
+	server.SetFile("master", "/vendor/lib/lib.go", "package lib")
+	server.SetFile("master", "/src/User.cs", "class User {}")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/pkg/a.go", "package pkg\n\nvar a = 1")
+	server.SetFile(testSourceBranch, "/pkg/b.go", "package pkg\n\nvar b = 1")
+	server.SetFile(testSourceBranch, "/pkg/b_test.go", "package pkg\n\nvar bt = 1")
+	server.SetFile(testSourceBranch, "/vendor/lib/lib.go", "package lib\n\nvar l = 1")
+	server.SetFile(testSourceBranch, "/src/User.cs", "class User { int Age; }")
+	server.SetFile(testSourceBranch, "/test/UserTests.cs", "class UserTests {}")
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	threads := server.Threads(testPullRequestID)
+	if len(threads) != 1 {
+		t.Fatalf("got %d threads, want 1: %+v", len(threads), threads)
+	}
+	thread := findTestThread(threads, "/pkg/a.go", "[BOT_Test]")
+	if thread == nil {
+		t.Fatalf("no test companion thread on /pkg/a.go: %+v", threads)
+	}
+	if !strings.Contains(thread.Comments[0].Content, "/pkg/a_test.go") {
+		t.Errorf("comment does not mention /pkg/a_test.go: %s", thread.Comments[0].Content)
+	}
+
+	// custom rules replace the defaults
+	client.config.TestCompanion = testCompanionConfig{
+		Rules: []testCompanionRule{{Sources: []string{"src/**/*.cs"}, Tests: []string{"test/{name}Spec.cs"}}},
+	}
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if findTestThread(server.Threads(testPullRequestID), "/src/User.cs", "[BOT_Test]") == nil {
+		t.Error("no test companion thread on /src/User.cs with custom rules")
+	}
+}
+
+func TestReviewThreadReconciliation(t *testing.T) {
+	server, client := newTestClient(t)
+
+	server.SetFile("master", "/pkg/a.go", "package pkg")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/pkg/a.go", "package pkg\n\nvar a = 1")
+
+	review := func() *vststest.Thread {
+		if err := client.Review(newTestPullRequest()); err != nil {
+			t.Fatal(err)
+		}
+		thread := findTestThread(server.Threads(testPullRequestID), "/pkg/a.go", "[BOT_Test]")
+		if thread == nil {
+			t.Fatal("no test companion thread on /pkg/a.go")
+		}
+		return thread
+	}
+
+	thread := review()
+	if thread.Status != "active" {
+		t.Errorf("thread status %s, want active", thread.Status)
+	}
+
+	// the thread is resolved once the finding disappears
+	server.SetFile(testSourceBranch, "/pkg/a_test.go", "package pkg")
+	if thread = review(); thread.Status != "fixed" {
+		t.Errorf("thread status %s after fix, want fixed", thread.Status)
+	}
+
+	// and reactivated without a new comment when it reappears
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/pkg/a.go", "package pkg\n\nvar a = 1")
+	if thread = review(); thread.Status != "active" || len(thread.Comments) != 1 {
+		t.Errorf("unexpected thread after finding reappeared: %+v", thread)
+	}
+	if threads := server.Threads(testPullRequestID); len(threads) != 1 {
+		t.Errorf("got %d threads, want 1", len(threads))
+	}
+
+	// threads resolved by a person are left untouched while the finding is unchanged
+	server.SetThreadStatus(testPullRequestID, thread.ID, "fixed")
+	if thread = review(); thread.Status != "fixed" || len(thread.Comments) != 1 {
+		t.Errorf("unexpected thread after it was resolved by a person: %+v", thread)
+	}
+
+	// threads closed by the author are left untouched while the finding is unchanged
+	server.SetThreadStatus(testPullRequestID, thread.ID, "closed")
+	if thread = review(); thread.Status != "closed" || len(thread.Comments) != 1 {
+		t.Errorf("unexpected thread after it was closed: %+v", thread)
+	}
+}
+
+func TestReviewThreadMarkers(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Summary = summaryConfig{Enabled: true}
+	suggestions := VoteApproveWithSuggestions
+	client.config.VotePolicy = votePolicy{Warning: &suggestions}
+
+	server.SetFile("master", "/pkg/a.go", "package pkg")
+	server.SetFile("master", "/pkg/b.go", "package pkg")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/pkg/a.go", "package pkg\n\nvar a = 1")
+	server.SetFile(testSourceBranch, "/pkg/b.go", "package pkg\n\nvar b = 1")
+
+	// threads created before markers are adopted
+	legacyThreadID := server.AddThread(testPullRequestID, vststest.Thread{
+		Status:        "active",
+		ThreadContext: &vststest.ThreadContext{FilePath: "/pkg/b.go"},
+		Comments:      []vststest.Comment{{ID: 1, Author: vststest.Author{ID: testUserID}, Content: "[BOT_Test]\nold wording", CommentType: "text"}},
+	})
+
+	findThreads := func(check string) []vststest.Thread {
+		var threads []vststest.Thread
+		for _, thread := range server.Threads(testPullRequestID) {
+			if thread.Properties[markerCheckProperty].Value == check {
+				threads = append(threads, thread)
+			}
+		}
+		return threads
+	}
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	threads := findThreads("testCompanion")
+	if len(threads) != 2 {
+		t.Fatalf("got %d marked test companion threads, want 2: %+v", len(threads), threads)
+	}
+	for _, thread := range threads {
+		if thread.FilePath() == "/pkg/b.go" && thread.ID != legacyThreadID {
+			t.Errorf("legacy thread %d was not adopted, got thread %d", legacyThreadID, thread.ID)
+		}
+		if thread.Properties[markerKeyProperty].Value != thread.FilePath() {
+			t.Errorf("thread key %v, want %s", thread.Properties[markerKeyProperty].Value, thread.FilePath())
+		}
+	}
+
+	// changing the visible prefix keeps the threads
+	prefix := ""
+	client.config.CommentPrefix = &prefix
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if threads := findThreads("testCompanion"); len(threads) != 2 {
+		t.Errorf("got %d test companion threads after prefix change, want 2", len(threads))
+	}
+	summaries := findThreads("summary")
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summary threads after prefix change, want 1", len(summaries))
+	}
+	if content := summaries[0].Comments[0].Content; strings.HasPrefix(content, "[BOT_") {
+		t.Errorf("summary still has the default prefix: %s", content)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != VoteApproveWithSuggestions {
+		t.Errorf("vote %d, want %d: comments in marked threads are no human comments", vote, VoteApproveWithSuggestions)
+	}
+}
+
+func TestReviewOwners(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Checks = map[string]bool{"owners": true}
+	client.config.Statuses = statusConfig{Enabled: true}
+	client.config.Owners = ownersConfig{
+		Required:   true,
+		Identities: map[string]string{"@storage": "storage-team-id"},
+	}
+
+	server.SetFile("master", "/CODEOWNERS", "* everyone-id\n/src/Entities/ @storage author-id\n/docs/ writer-id @docs removed-id\n")
+	server.SetUnknownIdentity("removed-id")
+	server.SetFile("master", "/src/Entities/User.cs", "class User {}")
+	server.SetFile("master", "/docs/index.md", "docs")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User { }")
+	server.SetFile(testSourceBranch, "/docs/index.md", "new docs")
+
+	pr := newTestPullRequest()
+	pr.Resource.CreatedBy.ID = "author-id"
+	pr.Resource.Reviewers = append(pr.Resource.Reviewers, IdentityRefWithVote{ID: "writer-id", IsRequired: true})
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []vststest.Reviewer{{ID: "storage-team-id", IsRequired: true}}
+	if got := server.Reviewers(testPullRequestID); !reflect.DeepEqual(got, want) {
+		t.Errorf("got reviewers %+v, want %+v", got, want)
+	}
+	// neither the unmapped @docs nor the unknown removed-id stop the review
+	if status, _ := server.Status(testPullRequestID, "owners"); status.State != "succeeded" || status.Description != "0 error(s), 2 warning(s), 0 info" {
+		t.Errorf("owners status %s: %s, want succeeded with 2 warnings", status.State, status.Description)
+	}
+}
+
+func TestReviewSize(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Checks = map[string]bool{"testCompanion": false, "size": true}
+	client.config.Size = sizeConfig{
+		Warning: sizeThresholds{Files: 2, Lines: -1},
+		Error:   sizeThresholds{Lines: 10},
+	}
+
+	server.SetFile("master", "/src/a.go", "a\nb\nc\n")
+	server.SetFile("master", "/src/old.go", "o\nl\nd\n")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/a.go", "a\nB\nc\n")
+	server.SetFile(testSourceBranch, "/src/b.go", "b\n")
+	server.DeleteFile(testSourceBranch, "/src/old.go")
+	server.SetFile(testSourceBranch, "/README.md", "readme\n")
+	server.SetFile(testSourceBranch, "/logo.png", "\x89PNG\n\n")
+	server.SetFile(testSourceBranch, "/vendor/lib/lib.go", "l\nl\nl\nl\nl\nl\nl\nl\nl\nl\nl\n")
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	thread := findTestThread(server.Threads(testPullRequestID), "", "[BOT_Size]")
+	if thread == nil {
+		t.Fatal("no size thread")
+	}
+	// binary and deleted files count without lines and without fetching them
+	for _, path := range []string{"/logo.png", "/src/old.go"} {
+		if requests := server.ItemRequests(path); requests != 0 {
+			t.Errorf("%s fetched %d times, want 0", path, requests)
+		}
+	}
+	for _, want := range []string{"**5** files with **+3 -1** lines, above the warning threshold of 2 files", "| `/` | 2 | 1 | 0 |", "| `/src` | 3 | 2 | 1 |"} {
+		if !strings.Contains(thread.Comments[0].Content, want) {
+			t.Errorf("size comment does not contain %q: %s", want, thread.Comments[0].Content)
+		}
+	}
+	if thread.Status != "active" {
+		t.Errorf("thread status %s, want active", thread.Status)
+	}
+
+	// the thread is updated in place once the pull request is small enough,
+	// unchanged files are not fetched again
+	requests := server.ItemRequests("/src/a.go")
+	client.config.Size.Warning.Files = 5
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.ItemRequests("/src/a.go"); got != requests {
+		t.Errorf("/src/a.go fetched %d times after second run, want %d", got, requests)
+	}
+	threads := server.Threads(testPullRequestID)
+	if len(threads) != 1 {
+		t.Fatalf("got %d threads, want 1", len(threads))
+	}
+	if threads[0].Status != "fixed" || len(threads[0].Comments) != 1 {
+		t.Errorf("thread %+v, want fixed with one comment", threads[0])
+	}
+}
+
+func TestReviewLargePullRequest(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Checks = map[string]bool{"testCompanion": false, "size": true}
+	client.config.Size = sizeConfig{Warning: sizeThresholds{Files: 2}}
+	client.config.Diffs = diffsConfig{PageSize: 2, MaxChanges: -1}
+
+	server.SetFile("master", "/README.md", "readme")
+	server.CopyBranch("master", testSourceBranch)
+	for i := 1; i <= 5; i++ {
+		server.SetFile(testSourceBranch, fmt.Sprintf("/docs/%d.md", i), "doc")
+	}
+
+	// all pages are reviewed
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	threads := server.Threads(testPullRequestID)
+	if thread := findTestThread(threads, "", "[BOT_Size]"); thread == nil || !strings.Contains(thread.Comments[0].Content, "changes **5** files") {
+		t.Errorf("size thread does not count all pages: %+v", thread)
+	}
+	if thread := findTestThread(threads, "", "[BOT_Completeness]"); thread != nil {
+		t.Errorf("unexpected completeness thread: %s", thread.Comments[0].Content)
+	}
+
+	// changes above the limit are not reviewed and a notice is posted instead of passing
+	client.config.Diffs.MaxChanges = 3
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	threads = server.Threads(testPullRequestID)
+	if thread := findTestThread(threads, "", "[BOT_Size]"); thread == nil || !strings.Contains(thread.Comments[0].Content, "changes **3** files") {
+		t.Errorf("size thread does not count the first 3 files: %+v", thread)
+	}
+	thread := findTestThread(threads, "", "[BOT_Completeness]")
+	if thread == nil {
+		t.Fatal("no completeness thread")
+	}
+	if !strings.Contains(thread.Comments[0].Content, "Only the first 3 changed files were reviewed") || thread.Status != "active" {
+		t.Errorf("unexpected completeness thread: %+v", thread)
+	}
+}
+
+func TestReviewMetadata(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Metadata = metadataConfig{
+		TitlePattern:         `^(feat|fix|docs)(\(.+\))?: `,
+		MinDescriptionLength: 30,
+		RequiredSections:     []string{"## Testing"},
+		BannedText:           []string{"<describe your change>"},
+	}
+
+	server.SetFile("master", "/README.md", "readme")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/README.md", "new readme")
+
+	pr := newTestPullRequest()
+	pr.Resource.Title = "Update readme"
+	pr.Resource.Description = "<describe your change>"
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	thread := findTestThread(server.Threads(testPullRequestID), "", "[BOT_Metadata]")
+	if thread == nil {
+		t.Fatal("no metadata thread")
+	}
+	for _, want := range []string{"Title must match", "at least 30 characters", "must contain `## Testing`", "must not contain `<describe your change>`"} {
+		if !strings.Contains(thread.Comments[0].Content, want) {
+			t.Errorf("metadata comment does not contain %q: %s", want, thread.Comments[0].Content)
+		}
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != VoteWaitForAuthor {
+		t.Errorf("vote %d, want %d", vote, VoteWaitForAuthor)
+	}
+
+	pr = newTestPullRequest()
+	pr.Resource.Title = "docs: update readme"
+	pr.Resource.Description = "Explain the new setup of the project.\n\n## Testing\nRead it twice."
+	setTestBotVote(pr, VoteWaitForAuthor)
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	thread = findTestThread(server.Threads(testPullRequestID), "", "[BOT_Metadata]")
+	if thread.Status != "fixed" || !strings.Contains(thread.Comments[0].Content, "look good") {
+		t.Errorf("thread %+v, want fixed and updated", thread)
+	}
+}
+
+func TestReviewWorkItems(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Checks = map[string]bool{"workItems": true}
+	client.config.WorkItems = workItemsConfig{
+		AllowedTypes:     []string{"Task", "Bug"},
+		DisallowedStates: []string{"Closed"},
+		AutoLink:         true,
+	}
+
+	server.SetFile("master", "/README.md", "readme")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/README.md", "new readme")
+	server.SetWorkItem(vststest.WorkItem{ID: 1, Type: "Task", State: "Active"})
+	server.SetWorkItem(vststest.WorkItem{ID: 2, Type: "Bug", State: "Closed"})
+	server.SetWorkItem(vststest.WorkItem{ID: 3, Type: "Epic", State: "Active"})
+
+	review := func(title string) *vststest.Thread {
+		pr := newTestPullRequest()
+		pr.Resource.Title = title
+		pr.Resource.Repository.ID = "repo-id"
+		pr.Resource.Repository.Project.ID = "project-id"
+		if err := client.Review(pr); err != nil {
+			t.Fatal(err)
+		}
+		thread := findTestThread(server.Threads(testPullRequestID), "", "[BOT_WorkItems]")
+		if thread == nil {
+			t.Fatal("no work items thread")
+		}
+		return thread
+	}
+
+	thread := review("Update readme AB#99")
+	for _, want := range []string{"AB#99 does not exist", "No work item is linked"} {
+		if !strings.Contains(thread.Comments[0].Content, want) {
+			t.Errorf("comment does not contain %q: %s", want, thread.Comments[0].Content)
+		}
+	}
+
+	thread = review("Update readme ab#1")
+	if linked := server.LinkedWorkItems(testPullRequestID); !reflect.DeepEqual(linked, []int{1}) {
+		t.Errorf("linked work items %v, want [1]", linked)
+	}
+	if thread.Status != "fixed" {
+		t.Errorf("thread status %s with valid work item, want fixed", thread.Status)
+	}
+
+	server.LinkWorkItem(testPullRequestID, 2)
+	server.LinkWorkItem(testPullRequestID, 3)
+	thread = review("Update readme AB#1")
+	for _, want := range []string{"AB#2 is Closed", "AB#3 has type Epic"} {
+		if !strings.Contains(thread.Comments[0].Content, want) {
+			t.Errorf("comment does not contain %q: %s", want, thread.Comments[0].Content)
+		}
+	}
+	if thread.Status != "active" {
+		t.Errorf("thread status %s with invalid work items, want active", thread.Status)
+	}
+}
+
+func TestReviewFreshness(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Freshness = freshnessConfig{Warning: 10, Error: 100}
+
+	server.SetFile("master", "/README.md", "readme")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/README.md", "new readme")
+	server.SetCommitCounts("master", testSourceBranch, 1, 5)
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if thread := findTestThread(server.Threads(testPullRequestID), "", "[BOT_Freshness]"); thread != nil {
+		t.Errorf("unexpected freshness thread on fresh branch: %s", thread.Comments[0].Content)
+	}
+
+	server.SetCommitCounts("master", testSourceBranch, 1, 20)
+	pr := newTestPullRequest()
+	pr.Resource.MergeStatus = "conflicts"
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	thread := findTestThread(server.Threads(testPullRequestID), "", "[BOT_Freshness]")
+	if thread == nil {
+		t.Fatal("no freshness thread")
+	}
+	for _, want := range []string{"20 commits behind `master`, more than 10", "merge conflicts with `master`"} {
+		if !strings.Contains(thread.Comments[0].Content, want) {
+			t.Errorf("comment does not contain %q: %s", want, thread.Comments[0].Content)
+		}
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != VoteWaitForAuthor {
+		t.Errorf("vote %d, want %d", vote, VoteWaitForAuthor)
+	}
+}
+
+func TestReviewIncremental(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.StorageEntitiesPrefix = []string{"/src/Entities"}
+	client.config.Incremental = incrementalConfig{Enabled: true}
+	client.config.Statuses = statusConfig{Enabled: true}
+
+	server.SetFile("master", "/src/Entities/User.cs", "class User\n{\n    public string Name { get; set; }\n}\n")
+	server.SetFile("master", "/src/Entities/Legacy.cs", "class Legacy\n{\n    public string Id { get; set; }\n}\n")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User\n{\n    // full name\n    public string Name { get; set; }\n}\n")
+	server.SetFile(testSourceBranch, "/src/Entities/Legacy.cs", "class Legacy\n{\n    public string Key { get; set; }\n}\n")
+	server.PushIteration(testPullRequestID, testSourceBranch, "master")
+
+	pr := newTestPullRequest()
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != -5 {
+		t.Errorf("vote %d, want -5", vote)
+	}
+	summary := findTestThread(server.Threads(testPullRequestID), "", "[BOT_Summary]")
+	if summary == nil {
+		t.Fatal("no summary thread keeping the review state")
+	}
+	if _, ok := summary.Properties[reviewStateProperty]; !ok {
+		t.Fatalf("summary thread has no review state: %+v", summary.Properties)
+	}
+	legacyRequests := server.ItemRequests("/src/Entities/Legacy.cs")
+
+	// only the file pushed in the new iteration is reviewed, the finding on the other file is kept.
+	// Credentials are not part of the configuration compared between reviews.
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User\n{\n    // display name\n    public string Name { get; set; }\n}\n")
+	server.PushIteration(testPullRequestID, testSourceBranch, "master")
+	userRequests := server.ItemRequests("/src/Entities/User.cs")
+	setTestBotVote(pr, -5)
+	client.config.Password = "rotated"
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	if requests := server.ItemRequests("/src/Entities/Legacy.cs"); requests != legacyRequests {
+		t.Errorf("unchanged file requested %d times, want %d", requests, legacyRequests)
+	}
+	if requests := server.ItemRequests("/src/Entities/User.cs"); requests == userRequests {
+		t.Error("changed file was not reviewed again")
+	}
+	summary = findTestThread(server.Threads(testPullRequestID), "", "[BOT_Summary]")
+	if !strings.Contains(summary.Comments[0].Content, "| storageEntities | :x: failed | 1 |") {
+		t.Errorf("summary does not keep the previous finding: %s", summary.Comments[0].Content)
+	}
+	if status, _ := server.Status(testPullRequestID, "storageEntities"); status.State != "failed" {
+		t.Errorf("status %+v, want failed", status)
+	}
+
+	// nothing is fetched when the source commit was already reviewed
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	if requests := server.ItemRequests("/src/Entities/User.cs"); requests != userRequests+2 {
+		t.Errorf("reviewed file requested %d times, want %d", requests, userRequests+2)
+	}
+
+	// fixing the file resolves the finding
+	server.SetFile(testSourceBranch, "/src/Entities/Legacy.cs", "class Legacy\n{\n    public string Id { get; set; }\n}\n")
+	server.PushIteration(testPullRequestID, testSourceBranch, "master")
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != 0 {
+		t.Errorf("vote %d after fix, want 0", vote)
+	}
+}
+
+func TestReviewImagesIncremental(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Endpoints = []string{server.URL}
+	client.config.ImageConfigs = []imageConfig{
+		{Os: "linux", ConfigPath: "/images.json", Header: "X-Image"},
+	}
+	client.config.Incremental = incrementalConfig{Enabled: true}
+
+	server.SetHealthHeader("X-Image", "v1")
+	server.SetFile("master", "/images.json", `{"commonImages":[]}`)
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/images.json", `{"commonImages":[{"name":"registry/image:v1"}]}`)
+	server.PushIteration(testPullRequestID, testSourceBranch, "master")
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if thread := findTestThread(server.Threads(testPullRequestID), "/images.json", "[BOT_Image]"); thread != nil && thread.Status == "active" {
+		t.Fatalf("unexpected image thread %+v", thread)
+	}
+
+	// an image deployed since the last review is flagged although the image list did not change
+	server.SetHealthHeader("X-Image", "v2")
+	server.SetFile(testSourceBranch, "/README.md", "readme")
+	server.PushIteration(testPullRequestID, testSourceBranch, "master")
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	thread := findTestThread(server.Threads(testPullRequestID), "/images.json", "[BOT_Image]")
+	if thread == nil || thread.Status != "active" || !strings.Contains(thread.Comments[len(thread.Comments)-1].Content, "v2") {
+		t.Errorf("image deployed since the last review not flagged: %+v", thread)
+	}
+}
+
+func TestReviewImages(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.Endpoints = []string{server.URL}
+	client.config.ImageConfigs = []imageConfig{
+		{Os: "linux", ConfigPath: "/images.json", Header: "X-Image"},
+	}
+
+	server.SetHealthHeader("X-Image", "v2")
+	server.SetFile("master", "/images.json", `{"commonImages":[{"name":"registry/image:v1"}]}`)
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/images.json", `{"commonImages":[{"name":"registry/image:v1"},{"name":"registry/image:v3"}]}`)
+
+	client.config.Statuses = statusConfig{Enabled: true, Genre: "bot"}
+	client.config.Summary = summaryConfig{Enabled: true}
+
+	pr := newTestPullRequest()
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, _ := server.Status(testPullRequestID, "image"); status.State != "failed" || status.Context.Genre != "bot" {
+		t.Errorf("unexpected image status %+v", status)
+	}
+	if status, _ := server.Status(testPullRequestID, "changeGroup"); status.State != "succeeded" {
+		t.Errorf("unexpected change group status %+v", status)
+	}
+
+	thread := findTestThread(server.Threads(testPullRequestID), "/images.json", "[BOT_Image]")
+	if thread == nil {
+		t.Fatal("no image thread on /images.json")
+	}
+	if thread.Status != "active" || !strings.Contains(thread.Comments[len(thread.Comments)-1].Content, "v2") {
+		t.Errorf("unexpected thread %+v", thread)
+	}
+	summary := findTestThread(server.Threads(testPullRequestID), "", "[BOT_Summary]")
+	if summary == nil {
+		t.Fatal("no summary thread")
+	}
+	imageRow := fmt.Sprintf("| image | :x: failed | 1 | 0 | 0 | #%d |", thread.ID)
+	if summary.Status != "active" || !strings.Contains(summary.Comments[0].Content, imageRow) {
+		t.Errorf("summary does not contain %q: %+v", imageRow, summary)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != -5 {
+		t.Errorf("vote %d, want -5", vote)
+	}
+
+	// fixing the image list resolves the thread and resets the vote
+	server.SetFile(testSourceBranch, "/images.json", `{"commonImages":[{"name":"registry/image:v1"},{"name":"registry/image:v2"}]}`)
+	setTestBotVote(pr, -5)
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+
+	thread = findTestThread(server.Threads(testPullRequestID), "/images.json", "[BOT_Image]")
+	if thread.Status != "fixed" || len(thread.Comments) != 2 {
+		t.Errorf("unexpected thread after fix %+v", thread)
+	}
+	if replies := thread.Properties[markerRepliesProperty].Value; replies != "2" {
+		t.Errorf("recorded bot replies %v, want 2", replies)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != 0 {
+		t.Errorf("vote %d after fix, want 0", vote)
+	}
+	if status, _ := server.Status(testPullRequestID, "image"); status.State != "succeeded" {
+		t.Errorf("unexpected image status after fix %+v", status)
+	}
+	if threads := server.Threads(testPullRequestID); len(threads) != 2 {
+		t.Errorf("got %d threads after fix, want image and summary", len(threads))
+	}
+	summary = findTestThread(server.Threads(testPullRequestID), "", "[BOT_Summary]")
+	if summary.Status != "fixed" || !strings.Contains(summary.Comments[0].Content, "| image | :white_check_mark: passed | 0 | 0 | 0 |  |") {
+		t.Errorf("summary not updated in place: %+v", summary)
+	}
+}
+
+func TestReviewDryRun(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.StorageEntitiesPrefix = []string{"/src/Entities"}
+	recorder := client.EnableDryRun()
+
+	server.SetFile("master", "/src/Entities/User.cs", "class User { public int Age { get; set; } }")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User { }")
+	server.SetFile(testSourceBranch, "/test/Entities/UserTests.cs", "class UserTests {}")
+
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	if threads := server.Threads(testPullRequestID); len(threads) != 0 {
+		t.Errorf("dry run created %d threads", len(threads))
+	}
+	if _, ok := server.Vote(testPullRequestID, testUserID); ok {
+		t.Error("dry run voted")
+	}
+
+	var actions []string
+	for _, call := range recorder.Calls() {
+		actions = append(actions, call.Action)
+	}
+	if strings.Join(actions, ",") != "createThread,vote" {
+		t.Errorf("recorded %v, want [createThread vote]", actions)
+	}
+}
+
+func TestReviewVotePolicy(t *testing.T) {
+	server, client := newTestClient(t)
+	approve, reject := VoteApprove, VoteReject
+	client.config.VotePolicy = votePolicy{Error: &reject, Pass: &approve}
+	client.config.StorageEntitiesPrefix = []string{"/src/Entities"}
+
+	server.SetFile("master", "/README.md", "readme")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/README.md", "new readme")
+
+	// a human comment blocks approval
+	humanThreadID := server.AddThread(testPullRequestID, vststest.Thread{
+		Status:   "active",
+		Comments: []vststest.Comment{{ID: 1, Author: vststest.Author{ID: testUserID}, Content: "looks odd", CommentType: "text"}},
+	})
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if vote, ok := server.Vote(testPullRequestID, testUserID); ok {
+		t.Errorf("voted %d despite human comment in thread %d", vote, humanThreadID)
+	}
+
+	client.config.VotePolicy.VoteWithHumanComments = true
+	if err := client.Review(newTestPullRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != VoteApprove {
+		t.Errorf("vote %d on clean PR, want %d", vote, VoteApprove)
+	}
+
+	// lowering the vote ignores human comments
+	server.SetFile("master", "/src/Entities/User.cs", "class User { public int Age { get; set; } }")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/src/Entities/User.cs", "class User { }")
+	client.config.VotePolicy.VoteWithHumanComments = false
+	pr := newTestPullRequest()
+	setTestBotVote(pr, VoteApprove)
+	if err := client.Review(pr); err != nil {
+		t.Fatal(err)
+	}
+	if vote, _ := server.Vote(testPullRequestID, testUserID); vote != VoteReject {
+		t.Errorf("vote %d on failed PR, want %d", vote, VoteReject)
+	}
+}
+
+func TestContainsHumanComments(t *testing.T) {
+	bot := vststest.Author{ID: testUserID}
+	human := vststest.Author{ID: "someone"}
+	marker := vststest.Properties{
+		markerCheckProperty:   {Type: "System.String", Value: "size"},
+		markerKeyProperty:     {Type: "System.String", Value: ""},
+		markerVersionProperty: {Type: "System.String", Value: markerVersion},
+	}
+	replies := vststest.Properties{markerRepliesProperty: {Type: "System.String", Value: "2"}}
+	for name, property := range marker {
+		replies[name] = property
+	}
+
+	tests := []struct {
+		name   string
+		thread vststest.Thread
+		want   bool
+	}{
+		{
+			name:   "marked bot thread with any prefix",
+			thread: vststest.Thread{Properties: replies, Comments: []vststest.Comment{{ID: 1, Author: bot, Content: "bot: size"}, {ID: 2, Author: bot, Content: "bot: smaller"}}},
+		},
+		{
+			name:   "reply in marked bot thread",
+			thread: vststest.Thread{Properties: replies, Comments: []vststest.Comment{{ID: 1, Author: bot, Content: "bot: size"}, {ID: 2, Author: bot, Content: "bot: smaller"}, {ID: 3, Author: bot, Content: "will split it"}}},
+			want:   true,
+		},
+		{
+			name:   "legacy bot thread",
+			thread: vststest.Thread{Comments: []vststest.Comment{{ID: 1, Author: bot, Content: "[BOT_Size]\nsize"}, {ID: 2, Author: bot, Content: "[BOT_Size]\nsmaller"}}},
+		},
+		{
+			name:   "reply in legacy bot thread",
+			thread: vststest.Thread{Comments: []vststest.Comment{{ID: 1, Author: bot, Content: "[BOT_Size]\nsize"}, {ID: 2, Author: bot, Content: "will split it"}}},
+			want:   true,
+		},
+		{
+			name:   "bot prefix in other thread",
+			thread: vststest.Thread{Comments: []vststest.Comment{{ID: 1, Author: human, Content: "why?"}, {ID: 2, Author: bot, Content: "[BOT_Size]\nsee above"}}},
+			want:   true,
+		},
+		{
+			name:   "marked thread of other author",
+			thread: vststest.Thread{Properties: marker, Comments: []vststest.Comment{{ID: 1, Author: human, Content: "copied"}, {ID: 2, Author: bot, Content: "ok"}}},
+			want:   true,
+		},
+		{
+			name:   "other author only",
+			thread: vststest.Thread{Comments: []vststest.Comment{{ID: 1, Author: human, Content: "why?"}}},
+		},
+		{
+			name:   "deleted comment",
+			thread: vststest.Thread{Comments: []vststest.Comment{{ID: 1, Author: bot, Content: "oops", IsDeleted: true}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, client := newTestClient(t)
+			prefix := "bot: "
+			client.config.CommentPrefix = &prefix
+			server.AddThread(testPullRequestID, test.thread)
+
+			got, err := client.containsHumanComments(newTestPullRequest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("containsHumanComments() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestVotePolicyInvalidVote(t *testing.T) {
+	invalid := 3
+	policy := votePolicy{Warning: &invalid}
+	if _, err := policy.getVote(SeverityWarning); err == nil {
+		t.Error("expected error for invalid vote")
+	}
+	if vote, err := policy.getVote(SeverityError); err != nil || vote != VoteWaitForAuthor {
+		t.Errorf("default error vote %d, %v", vote, err)
+	}
+}
@@ -0,0 +1,318 @@
+package vsts
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Finding is a single issue reported by a Reviewer.
+// Line and EndLine are the 1-based lines of the source version the finding is about, 0 for the whole file.
+// ThreadID is the thread the finding was commented in, 0 if none.
+type Finding struct {
+	FilePath string
+	Line     int
+	EndLine  int
+	Severity Severity
+	Message  string
+	ThreadID int
+}
+
+// Result is the outcome of a Reviewer run
+type Result struct {
+	Findings []Finding
+	// Comments are the threads the check keeps on files, reconciled with existing threads by Review
+	Comments []FileComment
+}
+
+// FileComment is the thread of a check on a file.
+// Review creates the thread, replies when the content changed, reactivates it when the bot resolved it
+// and resolves threads of the check on files without comment.
+// A comment without FilePath is the general thread of the check, see ReviewContext.addGeneralComment.
+type FileComment struct {
+	FilePath string
+	// Line and EndLine are the 1-based source lines the thread is attached to, 0 for the beginning of the file
+	Line    int
+	EndLine int
+	// AtChanges attaches the thread to the first changed lines of the file instead,
+	// they are only looked up when the thread is created
+	AtChanges bool
+	// Content is the comment body, the bot prepends its marker
+	Content string
+	// Passed resolves the thread, e.g. to report a file which was checked without findings
+	Passed bool
+}
+
+// ReviewContext is the input handed to every Reviewer
+type ReviewContext struct {
+	Client      *Client
+	PullRequest *PullRequest
+	diffs       *diffs
+
+	sourceVersion gitVersion
+	targetVersion gitVersion
+	baseVersion   gitVersion
+	sourceLines   map[string][]string
+	hunks         map[string][]Hunk
+	changes       map[string]Change
+
+	// threads are the comment threads before the first reconciliation, see getThreads
+	threads *commentThreads
+
+	// reviewPaths are the files changed since the last review in incremental reviews, nil to review all files
+	reviewPaths      map[string]bool
+	previousFindings map[string][]Finding
+}
+
+func newReviewContext(client *Client, pr *PullRequest, diffs *diffs) *ReviewContext {
+	return &ReviewContext{
+		Client:        client,
+		PullRequest:   pr,
+		diffs:         diffs,
+		sourceVersion: getSourceVersion(pr),
+		targetVersion: getTargetVersion(pr),
+		baseVersion:   getBaseVersion(diffs, getTargetVersion(pr)),
+		sourceLines:   make(map[string][]string),
+		hunks:         make(map[string][]Hunk),
+		changes:       make(map[string]Change),
+	}
+}
+
+// Changes returns the changes between the merge base and source version
+func (ctx *ReviewContext) Changes() []Change {
+	return ctx.diffs.Changes
+}
+
+// ReviewChanges returns the changes an IncrementalReviewer reviews,
+// only the files changed since the last review in incremental reviews
+func (ctx *ReviewContext) ReviewChanges() []Change {
+	if ctx.reviewPaths == nil {
+		return ctx.diffs.Changes
+	}
+
+	var changes []Change
+	for _, change := range ctx.diffs.Changes {
+		if ctx.reviewPaths[change.Item.Path] {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// SourceFile returns the content of a file in the source commit of the event, found is false if it does not exist
+func (ctx *ReviewContext) SourceFile(path string) (content string, found bool, err error) {
+	return ctx.Client.getItemText(ctx.sourceVersion, path)
+}
+
+// TargetFile returns the content of a file in the target commit of the event, found is false if it does not exist
+func (ctx *ReviewContext) TargetFile(path string) (content string, found bool, err error) {
+	return ctx.Client.getItemText(ctx.targetVersion, path)
+}
+
+// BaseFile returns the content of a file in the merge base of source and target, found is false if it does not exist.
+// Changes are computed against this version, so files changed only in the target are not part of the pull request.
+func (ctx *ReviewContext) BaseFile(path string) (content string, found bool, err error) {
+	return ctx.Client.getItemText(ctx.baseVersion, path)
+}
+
+// Hunks returns the changed line ranges of a file between base and source version
+func (ctx *ReviewContext) Hunks(path string) ([]Hunk, error) {
+	if hunks, ok := ctx.hunks[path]; ok {
+		return hunks, nil
+	}
+
+	key := getHunkCacheKey(ctx.getChange(path))
+	if hunks, ok := ctx.Client.hunks.get(key); ok && len(key) > 0 {
+		ctx.hunks[path] = hunks
+		return hunks, nil
+	}
+
+	base, _, err := ctx.BaseFile(ctx.getBasePath(path))
+	if err != nil {
+		return nil, err
+	}
+	sourceLines, err := ctx.getSourceLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hunks := diffLines(splitLines(base), sourceLines)
+	ctx.hunks[path] = hunks
+	if len(key) > 0 {
+		ctx.Client.hunks.set(key, hunks)
+	}
+
+	return hunks, nil
+}
+
+// getChange returns the change of a file, the zero Change if the file did not change
+func (ctx *ReviewContext) getChange(path string) Change {
+	if len(ctx.changes) == 0 {
+		for _, change := range ctx.diffs.Changes {
+			ctx.changes[change.Item.Path] = change
+		}
+	}
+	return ctx.changes[path]
+}
+
+// getBasePath returns the path of a file in the merge base, the original path of renamed files
+func (ctx *ReviewContext) getBasePath(path string) string {
+	change := ctx.getChange(path)
+	if len(change.OriginalPath) > 0 {
+		return change.OriginalPath
+	}
+	if len(change.SourceServerItem) > 0 {
+		return change.SourceServerItem
+	}
+	return path
+}
+
+func (ctx *ReviewContext) getSourceLines(path string) ([]string, error) {
+	if lines, ok := ctx.sourceLines[path]; ok {
+		return lines, nil
+	}
+
+	source, _, err := ctx.SourceFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := splitLines(source)
+	ctx.sourceLines[path] = lines
+	return lines, nil
+}
+
+// firstChangedLines returns the source lines of the first hunk of a file, 0 if no line is left in source
+func (ctx *ReviewContext) firstChangedLines(path string) (int, int, error) {
+	hunks, err := ctx.Hunks(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lines, err := ctx.getSourceLines(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, hunk := range hunks {
+		if hunk.RightStart <= len(lines) {
+			return hunk.RightStart, hunk.RightEnd(), nil
+		}
+	}
+
+	return 0, 0, nil
+}
+
+// getThreadContext attaches a thread to the lines of a finding
+func (ctx *ReviewContext) getThreadContext(finding Finding) (threadContext, error) {
+	if finding.FilePath == "" || finding.Line <= 0 {
+		return getFileThreadContext(finding.FilePath), nil
+	}
+
+	lines, err := ctx.getSourceLines(finding.FilePath)
+	if err != nil {
+		return threadContext{}, err
+	}
+	if finding.Line > len(lines) {
+		return getFileThreadContext(finding.FilePath), nil
+	}
+
+	endLine := finding.EndLine
+	if endLine < finding.Line {
+		endLine = finding.Line
+	}
+	if endLine > len(lines) {
+		endLine = len(lines)
+	}
+
+	return threadContext{
+		FilePath: finding.FilePath,
+		RightFileStart: filePosition{
+			Line:   finding.Line,
+			Offset: 1,
+		},
+		RightFileEnd: filePosition{
+			Line:   endLine,
+			Offset: len(lines[endLine-1]) + 1,
+		},
+	}, nil
+}
+
+// Reviewer is a single check run against a pull request
+type Reviewer interface {
+	// Name is the unique key of the check, used to enable or disable it in Config.Checks
+	Name() string
+	// Description is a short human readable summary of the check
+	Description() string
+	// Review runs the check, the vote is based on the severities of all findings
+	Review(ctx *ReviewContext) (*Result, error)
+}
+
+type registration struct {
+	reviewer Reviewer
+	enabled  bool
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []registration
+)
+
+func init() {
+	RegisterReviewer(&imageReview{}, true)
+	RegisterReviewer(&changeGroupReview{}, true)
+	RegisterReviewer(&storageEntitiesReview{}, true)
+	RegisterReviewer(&testCompanionReview{}, true)
+	RegisterReviewer(&ownersReview{}, false)
+	RegisterReviewer(&sizeReview{}, false)
+	RegisterReviewer(&metadataReview{}, true)
+	RegisterReviewer(&workItemsReview{}, false)
+	RegisterReviewer(&freshnessReview{}, true)
+	RegisterReviewer(&completenessReview{}, true)
+}
+
+// RegisterReviewer makes a Reviewer available to Review.
+// enabled is the default used when Config.Checks has no entry for the reviewer.
+// It panics if a reviewer with the same name is already registered.
+func RegisterReviewer(r Reviewer, enabled bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, reg := range registry {
+		if reg.reviewer.Name() == r.Name() {
+			panic(fmt.Sprintf("vsts: reviewer %s registered twice", r.Name()))
+		}
+	}
+
+	registry = append(registry, registration{r, enabled})
+}
+
+// Reviewers returns all registered reviewers in registration order
+func Reviewers() []Reviewer {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	reviewers := make([]Reviewer, 0, len(registry))
+	for _, reg := range registry {
+		reviewers = append(reviewers, reg.reviewer)
+	}
+
+	return reviewers
+}
+
+func enabledReviewers(config *Config) []Reviewer {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var reviewers []Reviewer
+	for _, reg := range registry {
+		enabled := reg.enabled
+		if e, ok := config.Checks[reg.reviewer.Name()]; ok {
+			enabled = e
+		}
+		if enabled {
+			reviewers = append(reviewers, reg.reviewer)
+		}
+	}
+
+	return reviewers
+}
@@ -0,0 +1,79 @@
+package vsts
+
+import (
+	"fmt"
+)
+
+// Severity is how serious a finding is
+type Severity string
+
+// Severities of findings, from least to most serious
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// severityPass is the aggregated severity of results without findings
+const severityPass Severity = ""
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityError:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func maxSeverity(a Severity, b Severity) Severity {
+	if b.rank() > a.rank() {
+		return b
+	}
+	return a
+}
+
+// Severity returns the most serious severity of all findings, empty if there is none
+func (r *Result) Severity() Severity {
+	severity := severityPass
+	for _, finding := range r.Findings {
+		severity = maxSeverity(severity, finding.Severity)
+	}
+	return severity
+}
+
+// Votes of a reviewer on a pull request
+const (
+	VoteApprove                = 10
+	VoteApproveWithSuggestions = 5
+	VoteNone                   = 0
+	VoteWaitForAuthor          = -5
+	VoteReject                 = -10
+)
+
+func (p votePolicy) getVote(severity Severity) (int, error) {
+	vote, policyVote := VoteNone, p.Pass
+	switch severity {
+	case SeverityError:
+		vote, policyVote = VoteWaitForAuthor, p.Error
+	case SeverityWarning:
+		policyVote = p.Warning
+	case SeverityInfo:
+		policyVote = p.Info
+	}
+
+	if policyVote != nil {
+		vote = *policyVote
+	}
+
+	switch vote {
+	case VoteApprove, VoteApproveWithSuggestions, VoteNone, VoteWaitForAuthor, VoteReject:
+		return vote, nil
+	default:
+		return 0, fmt.Errorf("invalid vote %d for severity '%s'", vote, severity)
+	}
+}
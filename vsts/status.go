@@ -0,0 +1,87 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// States of a pull request status
+const (
+	statusStatePending   = "pending"
+	statusStateSucceeded = "succeeded"
+	statusStateFailed    = "failed"
+	statusStateError     = "error"
+)
+
+const defaultStatusGenre = "vsts-pr"
+
+func (c *Client) getStatusesURL(pullRequestID int) string {
+	statusesURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/statuses?api-version={version}"
+	r := strings.NewReplacer(
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
+		"{pullRequest}", strconv.Itoa(pullRequestID),
+		"{version}", c.getAPIVersion(apiStatuses))
+
+	return r.Replace(statusesURLTemplate)
+}
+
+func (c *Client) setPullRequestStatus(pr *PullRequest, name string, state string, description string) error {
+	if !c.config.Statuses.Enabled {
+		return nil
+	}
+
+	log.Printf("Set PR %v status %s: %s...\n", pr.Resource.PullRequestID, name, state)
+
+	genre := c.config.Statuses.Genre
+	if len(genre) == 0 {
+		genre = defaultStatusGenre
+	}
+
+	targetURL := c.config.Statuses.TargetURL
+	if len(targetURL) == 0 {
+		targetURL = pr.Resource.Links.Web.Href
+	}
+
+	status := postStatus{
+		State:       state,
+		Description: description,
+		Context: statusContext{
+			Name:  name,
+			Genre: genre,
+		},
+		TargetURL: targetURL,
+	}
+
+	url := c.getStatusesURL(pr.Resource.PullRequestID)
+
+	err := c.postToVsts(url, status)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getResultStatus returns state and description of a check result
+func getResultStatus(result *Result) (string, string) {
+	counts := make(map[Severity]int)
+	for _, finding := range result.Findings {
+		counts[finding.Severity]++
+	}
+
+	state := statusStateSucceeded
+	if result.Severity() == SeverityError {
+		state = statusStateFailed
+	}
+
+	if len(result.Findings) == 0 {
+		return state, "passed"
+	}
+
+	return state, fmt.Sprintf("%d error(s), %d warning(s), %d info", counts[SeverityError], counts[SeverityWarning], counts[SeverityInfo])
+}
@@ -0,0 +1,120 @@
+package vsts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// summaryMarker identifies the summary thread
+var summaryMarker = threadMarker{Check: "summary"}
+
+func getSummaryCommentPrefix() string {
+	return "[BOT_Summary]\n"
+}
+
+// checkSummary is the result of a single check in the summary thread
+type checkSummary struct {
+	reviewer Reviewer
+	result   *Result
+}
+
+func getSeverityText(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return ":x: failed"
+	case SeverityWarning:
+		return ":warning: warning"
+	case SeverityInfo:
+		return ":information_source: info"
+	default:
+		return ":white_check_mark: passed"
+	}
+}
+
+func getThreadLink(pr *PullRequest, threadID int) string {
+	href := pr.Resource.Links.Web.Href
+	if len(href) == 0 {
+		return fmt.Sprintf("#%d", threadID)
+	}
+	return fmt.Sprintf("[#%d](%s?discussionId=%d)", threadID, href, threadID)
+}
+
+func getSummaryContent(pr *PullRequest, summaries []checkSummary, severity Severity) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "**Review result: %s**\n\n", getSeverityText(severity))
+	b.WriteString("| Check | Result | Errors | Warnings | Info | Threads |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+
+	for _, summary := range summaries {
+		counts := make(map[Severity]int)
+		threadIDMap := make(map[int]bool)
+		for _, finding := range summary.result.Findings {
+			counts[finding.Severity]++
+			if finding.ThreadID > 0 {
+				threadIDMap[finding.ThreadID] = true
+			}
+		}
+
+		var threadIDs []int
+		for threadID := range threadIDMap {
+			threadIDs = append(threadIDs, threadID)
+		}
+		sort.Ints(threadIDs)
+
+		var links []string
+		for _, threadID := range threadIDs {
+			links = append(links, getThreadLink(pr, threadID))
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %s |\n",
+			summary.reviewer.Name(),
+			getSeverityText(summary.result.Severity()),
+			counts[SeverityError],
+			counts[SeverityWarning],
+			counts[SeverityInfo],
+			strings.Join(links, " "))
+	}
+
+	b.WriteString("\n*This comment was added by bot and is updated on every push.*")
+	return b.String()
+}
+
+// updateSummary creates or updates in place the summary thread with results of all checks,
+// state of incremental reviews is kept in a hidden property of the thread
+func (c *Client) updateSummary(pr *PullRequest, summaries []checkSummary, severity Severity, state *reviewState) error {
+	if !c.config.Summary.Enabled && state == nil {
+		return nil
+	}
+
+	content := c.getCommentPrefix(summaryMarker.Check, getSummaryCommentPrefix()) + getSummaryContent(pr, summaries, severity)
+	status := 2
+	if severity == SeverityError {
+		status = 1
+	}
+
+	var properties threadProperties
+	if state != nil {
+		value, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		properties = threadProperties{
+			reviewStateProperty: {
+				Type:  "System.String",
+				Value: string(value),
+			},
+		}
+	}
+
+	commentThreads, err := c.getCommentThreads(pr.Resource.PullRequestID)
+	if err != nil {
+		return err
+	}
+
+	thread := c.findBotThread(commentThreads, summaryMarker, getSummaryCommentPrefix())
+	_, err = c.updateBotThread(pr.Resource.PullRequestID, summaryMarker, thread, content, status, properties)
+	return err
+}
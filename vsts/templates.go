@@ -0,0 +1,80 @@
+package vsts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// CommentData is the data model of comment templates, see README for template names
+type CommentData struct {
+	// Check is the name of the check adding the comment
+	Check string
+	// PullRequest is the reviewed pull request, e.g. {{.PullRequest.Resource.Title}}
+	PullRequest *PullRequest
+	// Files are the files the comment is about
+	Files []string
+	// Missing are the missing items, e.g. images not in an image list or files of a change group not updated
+	Missing []string
+	// Changes are the breaking changes of a storage entity
+	Changes []string
+	// Problems are the failing rules of the pull request title and description, of linked work items or of the source branch
+	Problems []string
+	// Size is the size of the pull request
+	Size *PullRequestSize
+	// Severity is the most serious severity of the findings the comment is about, empty if there is none
+	Severity Severity
+	// Message is the custom message of the rule the comment is about or the notice of a pull request too large to review fully, if any
+	Message string
+}
+
+const botCommentSuffix = "\n*This comment was added by bot, please let me know if you have any suggestion!*"
+
+var defaultTemplates = map[string]string{
+	"completeness":        "{{if .Message}}:warning: {{.Message}}\nPlease review the remaining files carefully or consider splitting the pull request.\n{{else}}:white_check_mark: All changes of this pull request were reviewed.\n{{end}}" + botCommentSuffix,
+	"freshness":           "{{if .Problems}}{{if eq .Severity \"error\"}}:x:{{else}}:warning:{{end}} Please update the source branch of this pull request:\n{{range .Problems}}- {{.}}\n{{end}}{{else}}:white_check_mark: The source branch of this pull request is up to date.\n{{end}}" + botCommentSuffix,
+	"image.passed":        ":white_check_mark: All images listed on [acihealth](http://acihealth.azurewebsites.net/#cloudshell) are included in this list.\n" + botCommentSuffix,
+	"image.failed":        ":x: Following images should be included:**{{.Missing}}**\nYou can get image list from [acihealth](http://acihealth.azurewebsites.net/#cloudshell).\n" + botCommentSuffix,
+	"changeGroup":         ":warning: {{if .Message}}{{.Message}}{{else}}These files are usually updated together: **{{.Files}}**, please double check.{{end}}\n" + botCommentSuffix,
+	"changeGroup.require": ":warning: {{if .Message}}{{.Message}}{{else}}Files matching **{{join .Files \", \"}}** changed, please also update **{{join .Missing \" or \"}}**.{{end}}\n" + botCommentSuffix,
+	"storageEntities":     ":x: **{{index .Files 0}}** has breaking changes:\n{{range .Changes}}- {{.}}\n{{end}}Existing entities in storage may not be readable anymore, please keep back compatibility.\n" + botCommentSuffix,
+	"metadata":            "{{if .Problems}}:x: Please update the title or description of this pull request:\n{{range .Problems}}- {{.}}\n{{end}}{{else}}:white_check_mark: Title and description of this pull request look good.\n{{end}}" + botCommentSuffix,
+	"workItems":           "{{if .Problems}}:x: Please link this pull request to a valid work item:\n{{range .Problems}}- {{.}}\n{{end}}\nWork items referenced as AB#1234 in title, description or source branch can be linked by the bot.\n{{else}}:white_check_mark: This pull request is linked to a valid work item.\n{{end}}" + botCommentSuffix,
+	"size":                "{{if eq .Severity \"error\"}}:x:{{else if .Severity}}:warning:{{else}}:white_check_mark:{{end}} This pull request changes **{{.Size.Files}}** files with **+{{.Size.Added}} -{{.Size.Removed}}** lines{{if .Message}}, {{.Message}}{{end}}.\n\n| Directory | Files | Added | Removed |\n| --- | --- | --- | --- |\n{{range .Size.Directories}}| `{{.Path}}` | {{.Files}} | {{.Added}} | {{.Removed}} |\n{{end}}{{if .Severity}}\nSmaller pull requests are reviewed faster, please consider splitting it.\n{{end}}" + botCommentSuffix,
+	"testCompanion":       ":warning: **{{index .Files 0}}** was changed without tests, please also update **{{join .Missing \" or \"}}**.\n" + botCommentSuffix,
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// renderComment renders the configured or default template with data
+func (c *Client) renderComment(name string, data CommentData) (string, error) {
+	text, ok := c.config.Templates[name]
+	if !ok {
+		text, ok = defaultTemplates[name]
+	}
+	if !ok {
+		return "", fmt.Errorf("template '%s' not found", name)
+	}
+
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("template '%s': %v", name, err)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("template '%s': %v", name, err)
+	}
+
+	return b.String(), nil
+}
+
+// renderComment renders a comment of a check about the reviewed pull request
+func (ctx *ReviewContext) renderComment(name string, check string, data CommentData) (string, error) {
+	data.Check = check
+	data.PullRequest = ctx.PullRequest
+	return ctx.Client.renderComment(name, data)
+}
@@ -0,0 +1,213 @@
+package vsts
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// botCommenter is a Reviewer with its own default prefix of bot comments
+type botCommenter interface {
+	getBotCommentPrefix() string
+}
+
+// getDefaultCommentPrefix returns the default prefix of the comments of a check,
+// also used to recognize threads created before markers
+func getDefaultCommentPrefix(reviewer Reviewer) string {
+	if commenter, ok := reviewer.(botCommenter); ok {
+		return commenter.getBotCommentPrefix()
+	}
+	return fmt.Sprintf("[BOT_%s]\n", reviewer.Name())
+}
+
+// getCommentPrefix returns the visible prefix of the comments of a check
+func (ctx *ReviewContext) getCommentPrefix(reviewer Reviewer) string {
+	return ctx.Client.getCommentPrefix(reviewer.Name(), getDefaultCommentPrefix(reviewer))
+}
+
+// addGeneralComment adds the general comment of a check rendered with the template named after the check,
+// the comment passes while the result has no findings
+func (ctx *ReviewContext) addGeneralComment(reviewer Reviewer, result *Result, data CommentData) error {
+	body, err := ctx.renderComment(reviewer.Name(), reviewer.Name(), data)
+	if err != nil {
+		return err
+	}
+
+	result.Comments = append(result.Comments, FileComment{Content: body, Passed: len(result.Findings) == 0})
+	return nil
+}
+
+// getThreads returns the comment threads of the pull request, fetched once per review.
+// Threads of a check are only changed by its own reconciliation, so they are never stale.
+func (ctx *ReviewContext) getThreads() (*commentThreads, error) {
+	if ctx.threads != nil {
+		return ctx.threads, nil
+	}
+
+	threads, err := ctx.Client.getCommentThreads(ctx.PullRequest.Resource.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	ctx.threads = threads
+	return threads, nil
+}
+
+// reconcileThreads brings the threads of a check in line with the comments of its result:
+// file threads are created for new comments, updated when the comment changed or was resolved before,
+// and resolved when the file has no comment anymore, marking threads created before markers.
+// Threads of unchanged comments are left untouched.
+// The general thread is only created once the check fails and is updated in place on every run.
+// Findings with a comment on their file, or the general comment for findings without file, are linked to its thread.
+func (ctx *ReviewContext) reconcileThreads(reviewer Reviewer, result *Result) error {
+	pullRequestID := ctx.PullRequest.Resource.PullRequestID
+	prefix := ctx.getCommentPrefix(reviewer)
+	legacyPrefix := getDefaultCommentPrefix(reviewer)
+
+	commentThreads, err := ctx.getThreads()
+	if err != nil {
+		return err
+	}
+
+	threadIDs := make(map[string]int)
+	for _, comment := range result.Comments {
+		content := prefix + comment.Content
+		status := 1
+		if comment.Passed {
+			status = 2
+		}
+
+		marker := threadMarker{Check: reviewer.Name(), Key: comment.FilePath}
+		thread := ctx.Client.findBotThread(commentThreads, marker, legacyPrefix)
+		if len(comment.FilePath) == 0 {
+			// only comment once the check fails
+			if thread == nil && comment.Passed {
+				continue
+			}
+			threadID, err := ctx.Client.updateBotThread(pullRequestID, marker, thread, content, status, nil)
+			if err != nil {
+				return err
+			}
+			threadIDs[""] = threadID
+			continue
+		}
+		if thread == nil {
+			if comment.AtChanges {
+				comment.Line, comment.EndLine, err = ctx.firstChangedLines(comment.FilePath)
+				if err != nil {
+					return err
+				}
+			}
+			threadContext, err := ctx.getThreadContext(Finding{FilePath: comment.FilePath, Line: comment.Line, EndLine: comment.EndLine})
+			if err != nil {
+				return err
+			}
+			properties := marker.properties()
+			properties[markerResolvedProperty] = getBotResolvedProperty(comment.Passed)
+			createdThread, err := ctx.Client.createCommentThreadWithProperties(pullRequestID, threadContext, status, content, properties)
+			if err != nil {
+				return err
+			}
+			threadIDs[strings.ToLower(comment.FilePath)] = createdThread.ID
+			continue
+		}
+		threadIDs[strings.ToLower(comment.FilePath)] = thread.ID
+
+		properties := ctx.Client.getBotThreadProperties(*thread, marker)
+		changed := !strings.Contains(getLastComment(*thread), comment.Content)
+		if changed {
+			reply, err := ctx.Client.addComment(pullRequestID, *thread, comment.Content, content)
+			if err != nil {
+				return err
+			}
+			// replies of the bot are told apart from replies of humans on the bot account
+			if reply != nil && reply.ID > 0 {
+				properties[markerRepliesProperty] = getBotRepliesProperty(append(ctx.Client.getBotReplyIDs(*thread), reply.ID))
+			}
+		}
+
+		// threads resolved or closed by humans stay that way until the comment changes
+		if comment.Passed || changed || (strings.EqualFold(thread.Status, "fixed") && isResolvedByBot(*thread)) {
+			err := ctx.Client.setCommentThreadStatus(pullRequestID, *thread, status)
+			if err != nil {
+				return err
+			}
+			properties[markerResolvedProperty] = getBotResolvedProperty(comment.Passed)
+		}
+
+		err := ctx.Client.setCommentThreadProperties(pullRequestID, *thread, properties)
+		if err != nil {
+			return err
+		}
+	}
+
+	// General threads of checks commenting on files are left from before file threads and resolved as well.
+	// Threads created before markers get their marker once, so they are recognized without their prefix.
+	for _, thread := range commentThreads.Value {
+		marker, ok := ctx.Client.getBotThreadMarker(thread, reviewer.Name(), legacyPrefix)
+		filePath := marker.Key
+		if !ok {
+			continue
+		}
+		if _, ok := threadIDs[strings.ToLower(filePath)]; ok {
+			continue
+		}
+		_, marked := getThreadMarker(thread)
+		resolve := strings.EqualFold(thread.Status, "active") && ctx.isReviewed(reviewer, filePath)
+		if marked && !resolve {
+			continue
+		}
+
+		if resolve {
+			log.Printf("resolving thread %v of check %s on '%s'\n", thread.ID, reviewer.Name(), filePath)
+			err := ctx.Client.setCommentThreadStatus(pullRequestID, thread, 2)
+			if err != nil {
+				return err
+			}
+		}
+		properties := ctx.Client.getBotThreadProperties(thread, marker)
+		if resolve {
+			properties[markerResolvedProperty] = getBotResolvedProperty(true)
+		}
+		err = ctx.Client.setCommentThreadProperties(pullRequestID, thread, properties)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, finding := range result.Findings {
+		if threadID, ok := threadIDs[strings.ToLower(finding.FilePath)]; ok && finding.ThreadID == 0 {
+			result.Findings[i].ThreadID = threadID
+		}
+	}
+
+	return nil
+}
+
+// isReviewed reports whether a check looked at a file in this run,
+// files of incremental checks which did not change since the last review keep their threads
+func (ctx *ReviewContext) isReviewed(reviewer Reviewer, filePath string) bool {
+	incremental, ok := reviewer.(IncrementalReviewer)
+	if !ok || !incremental.Incremental() || ctx.reviewPaths == nil || ctx.reviewPaths[filePath] {
+		return true
+	}
+
+	for _, change := range ctx.diffs.Changes {
+		if strings.EqualFold(change.Item.Path, filePath) {
+			return false
+		}
+	}
+	return true
+}
+
+// getLastComment returns the content of the latest comment of a thread
+func getLastComment(thread commentThread) string {
+	lastCommentID := 0
+	content := ""
+	for _, comment := range thread.Comments {
+		if !comment.IsDeleted && comment.ID > lastCommentID {
+			lastCommentID = comment.ID
+			content = comment.Content
+		}
+	}
+	return content
+}
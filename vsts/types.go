@@ -1,5 +1,7 @@
 package vsts
 
+import "encoding/json"
+
 type author struct {
 	ID string `json:"id"`
 }
@@ -25,11 +27,12 @@ type threadContext struct {
 }
 
 type commentThread struct {
-	ID            int           `json:"id"`
-	Comments      []comment     `json:"comments"`
-	Status        string        `json:"status"`
-	ThreadContext threadContext `json:"threadContext"`
-	IsDeleted     bool          `json:"isDeleted"`
+	ID            int              `json:"id"`
+	Comments      []comment        `json:"comments"`
+	Status        string           `json:"status"`
+	ThreadContext threadContext    `json:"threadContext"`
+	Properties    threadProperties `json:"properties"`
+	IsDeleted     bool             `json:"isDeleted"`
 }
 
 type commentThreads struct {
@@ -43,52 +46,146 @@ type postComment struct {
 	CommentType     int    `json:"commentType"`
 }
 
-type supportsMarkDown struct {
-	Type  string `json:"type"`
-	Value int    `json:"value"`
+type patchComment struct {
+	Content string `json:"content"`
 }
 
+// threadProperty is a typed value, sent as type and value but returned as $type and $value
 type threadProperty struct {
-	MicrosoftTeamFoundationDiscussionSupportsMarkdown supportsMarkDown `json:"Microsoft.TeamFoundation.Discussion.SupportsMarkdown"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+func (p *threadProperty) UnmarshalJSON(data []byte) error {
+	var property struct {
+		Type        string      `json:"type"`
+		Value       interface{} `json:"value"`
+		DollarType  string      `json:"$type"`
+		DollarValue interface{} `json:"$value"`
+	}
+	if err := json.Unmarshal(data, &property); err != nil {
+		return err
+	}
+
+	p.Type, p.Value = property.Type, property.Value
+	if len(property.DollarType) > 0 {
+		p.Type, p.Value = property.DollarType, property.DollarValue
+	}
+	return nil
+}
+
+type threadProperties map[string]threadProperty
+
+// getString returns the value of a string property, empty if it is not set
+func (p threadProperties) getString(name string) string {
+	if value, ok := p[name].Value.(string); ok {
+		return value
+	}
+	return ""
 }
 
 type postThread struct {
-	Comments      []postComment  `json:"comments"`
-	Properties    threadProperty `json:"properties"`
-	Status        int            `json:"status"`
-	ThreadContext threadContext  `json:"threadContext"`
+	Comments      []postComment    `json:"comments"`
+	Properties    threadProperties `json:"properties"`
+	Status        int              `json:"status"`
+	ThreadContext threadContext    `json:"threadContext"`
 }
 
 type patchThread struct {
 	Status int `json:"status"`
 }
 
+type patchThreadProperties struct {
+	Properties threadProperties `json:"properties"`
+}
+
 type putVote struct {
 	Vote int `json:"vote"`
 }
 
+type putReviewer struct {
+	IsRequired bool `json:"isRequired"`
+}
+
+type statusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre"`
+}
+
+type postStatus struct {
+	State       string        `json:"state"`
+	Description string        `json:"description"`
+	Context     statusContext `json:"context"`
+	TargetURL   string        `json:"targetUrl,omitempty"`
+}
+
 type diffs struct {
 	AllChangesIncluded bool `json:"allChangesIncluded"`
 	ChangeCounts       struct {
 		Edit int `json:"Edit"`
 	} `json:"changeCounts"`
-	Changes []struct {
-		Item struct {
-			ObjectID         string `json:"objectId"`
-			OriginalObjectID string `json:"originalObjectId"`
-			GitObjectType    string `json:"gitObjectType"`
-			CommitID         string `json:"commitId"`
-			Path             string `json:"path"`
-			IsFolder         bool   `json:"isFolder"`
-			URL              string `json:"url"`
-		} `json:"item"`
-		ChangeType string `json:"changeType"`
-	} `json:"changes"`
-	CommonCommit string `json:"commonCommit"`
-	BaseCommit   string `json:"baseCommit"`
-	TargetCommit string `json:"targetCommit"`
-	AheadCount   int    `json:"aheadCount"`
-	BehindCount  int    `json:"behindCount"`
+	Changes      []Change `json:"changes"`
+	CommonCommit string   `json:"commonCommit"`
+	BaseCommit   string   `json:"baseCommit"`
+	TargetCommit string   `json:"targetCommit"`
+	AheadCount   int      `json:"aheadCount"`
+	BehindCount  int      `json:"behindCount"`
+}
+
+// Change is a single changed item between two versions of the repository
+type Change struct {
+	Item struct {
+		ObjectID         string `json:"objectId"`
+		OriginalObjectID string `json:"originalObjectId"`
+		GitObjectType    string `json:"gitObjectType"`
+		CommitID         string `json:"commitId"`
+		Path             string `json:"path"`
+		IsFolder         bool   `json:"isFolder"`
+		URL              string `json:"url"`
+	} `json:"item"`
+	ChangeType string `json:"changeType"`
+	// OriginalPath, or SourceServerItem in older api-versions, is the path of a renamed item before the rename
+	OriginalPath     string `json:"originalPath"`
+	SourceServerItem string `json:"sourceServerItem"`
+}
+
+type resourceRef struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+type resourceRefs struct {
+	Count int           `json:"count"`
+	Value []resourceRef `json:"value"`
+}
+
+type workItem struct {
+	ID     int `json:"id"`
+	Fields struct {
+		WorkItemType string `json:"System.WorkItemType"`
+		State        string `json:"System.State"`
+		Title        string `json:"System.Title"`
+	} `json:"fields"`
+}
+
+type workItems struct {
+	Count int        `json:"count"`
+	Value []workItem `json:"value"`
+}
+
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// jsonPatch is sent as application/json-patch+json
+type jsonPatch []jsonPatchOperation
+
+type workItemRelation struct {
+	Rel        string            `json:"rel"`
+	URL        string            `json:"url"`
+	Attributes map[string]string `json:"attributes"`
 }
 
 type image struct {
@@ -101,3 +198,35 @@ type imageList struct {
 	Common       []string `json:"common"`
 	CommonImages []image  `json:"commonImages"`
 }
+
+type commitRef struct {
+	CommitID string `json:"commitId"`
+}
+
+type pullRequestIteration struct {
+	ID              int       `json:"id"`
+	SourceRefCommit commitRef `json:"sourceRefCommit"`
+	TargetRefCommit commitRef `json:"targetRefCommit"`
+	CommonRefCommit commitRef `json:"commonRefCommit"`
+}
+
+type pullRequestIterations struct {
+	Count int                    `json:"count"`
+	Value []pullRequestIteration `json:"value"`
+}
+
+type iterationChange struct {
+	ChangeTrackingID int `json:"changeTrackingId"`
+	ChangeID         int `json:"changeId"`
+	Item             struct {
+		Path string `json:"path"`
+	} `json:"item"`
+	OriginalPath string `json:"originalPath"`
+	ChangeType   string `json:"changeType"`
+}
+
+type iterationChanges struct {
+	ChangeEntries []iterationChange `json:"changeEntries"`
+	NextSkip      int               `json:"nextSkip"`
+	NextTop       int               `json:"nextTop"`
+}
@@ -0,0 +1,63 @@
+package vsts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// API names used as keys of Config.APIVersions
+const (
+	apiThreads   = "threads"
+	apiComments  = "comments"
+	apiDiffs     = "diffs"
+	apiItems     = "items"
+	apiReviewers = "reviewers"
+	apiStatuses  = "statuses"
+	// apiIterations lists iterations of pull requests and their changes
+	apiIterations = "iterations"
+	// apiPullRequestWorkItems lists work items of pull requests, apiWorkItems reads and links work items
+	apiPullRequestWorkItems = "pullRequestWorkItems"
+	apiWorkItems            = "workItems"
+)
+
+var defaultAPIVersions = map[string]string{
+	apiThreads:   "3.0-preview",
+	apiComments:  "3.0-preview",
+	apiDiffs:     "1.0",
+	apiItems:     "1.0",
+	apiReviewers: "3.0-preview",
+	apiStatuses:  "4.0-preview",
+
+	apiIterations:           "4.1",
+	apiPullRequestWorkItems: "4.1",
+	apiWorkItems:            "4.1",
+}
+
+// getCollectionURL returns the collection (or organization) root URL, e.g.
+// https://fabrikam.visualstudio.com/DefaultCollection, https://dev.azure.com/fabrikam
+// or http://tfs.fabrikam.com:8080/tfs/FabrikamCollection
+func (c *Client) getCollectionURL() string {
+	scheme := c.config.Scheme
+	if len(scheme) == 0 {
+		scheme = "https"
+	}
+
+	instance := strings.TrimSuffix(c.config.Instance, "/")
+	collection := strings.Trim(c.config.Collection, "/")
+	if len(collection) == 0 && strings.HasSuffix(strings.ToLower(instance), ".visualstudio.com") {
+		// Hosted accounts always had a single default collection.
+		collection = "DefaultCollection"
+	}
+
+	if len(collection) == 0 {
+		return fmt.Sprintf("%s://%s", scheme, instance)
+	}
+	return fmt.Sprintf("%s://%s/%s", scheme, instance, collection)
+}
+
+func (c *Client) getAPIVersion(api string) string {
+	if version, ok := c.config.APIVersions[api]; ok && len(version) > 0 {
+		return version
+	}
+	return defaultAPIVersions[api]
+}
@@ -0,0 +1,74 @@
+package vsts
+
+import (
+	"testing"
+)
+
+func TestGetCollectionURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"visualstudio.com", Config{Instance: "fabrikam.visualstudio.com"}, "https://fabrikam.visualstudio.com/DefaultCollection"},
+		{"visualstudio.com collection", Config{Instance: "fabrikam.visualstudio.com/", Collection: "/Other/"}, "https://fabrikam.visualstudio.com/Other"},
+		{"dev.azure.com", Config{Instance: "dev.azure.com", Collection: "fabrikam"}, "https://dev.azure.com/fabrikam"},
+		{"tfs", Config{Scheme: "http", Instance: "tfs.fabrikam.com:8080/tfs", Collection: "FabrikamCollection"}, "http://tfs.fabrikam.com:8080/tfs/FabrikamCollection"},
+		{"no collection", Config{Scheme: "http", Instance: "localhost:8080"}, "http://localhost:8080"},
+	}
+
+	for _, test := range tests {
+		client := NewClient(&test.config, nil)
+		if got := client.getCollectionURL(); got != test.want {
+			t.Errorf("%s: getCollectionURL() = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetThreadsURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{
+			name:   "default version",
+			config: Config{Instance: "dev.azure.com", Collection: "fabrikam", Project: "Fabrikam Fiber", Repo: "web#app"},
+			want:   "https://dev.azure.com/fabrikam/Fabrikam%20Fiber/_apis/git/repositories/web%23app/pullRequests/7/threads?api-version=3.0-preview",
+		},
+		{
+			name: "overridden version",
+			config: Config{Instance: "dev.azure.com", Collection: "fabrikam", Project: "project", Repo: "repo",
+				APIVersions: map[string]string{apiThreads: "5.0", apiDiffs: "5.1"}},
+			want: "https://dev.azure.com/fabrikam/project/_apis/git/repositories/repo/pullRequests/7/threads?api-version=5.0",
+		},
+		{
+			name: "empty override",
+			config: Config{Instance: "dev.azure.com", Collection: "fabrikam", Project: "project", Repo: "repo",
+				APIVersions: map[string]string{apiThreads: ""}},
+			want: "https://dev.azure.com/fabrikam/project/_apis/git/repositories/repo/pullRequests/7/threads?api-version=3.0-preview",
+		},
+	}
+
+	for _, test := range tests {
+		client := NewClient(&test.config, nil)
+		if got := client.getThreadsURL(7); got != test.want {
+			t.Errorf("%s: getThreadsURL() = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetAPIVersion(t *testing.T) {
+	client := NewClient(&Config{APIVersions: map[string]string{apiIterations: "5.0"}}, nil)
+
+	for api, want := range map[string]string{
+		apiIterations: "5.0",
+		apiThreads:    "3.0-preview",
+		apiStatuses:   "4.0-preview",
+		apiWorkItems:  "4.1",
+	} {
+		if got := client.getAPIVersion(api); got != want {
+			t.Errorf("getAPIVersion(%s) = %s, want %s", api, got, want)
+		}
+	}
+}
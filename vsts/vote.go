@@ -2,33 +2,51 @@ package vsts
 
 import (
 	"log"
+	"net/url"
 	"strconv"
 	"strings"
 )
 
-func getReviewerURL(pullRequestID int) string {
-	reviewerURLTemplate := "https://{instance}/DefaultCollection/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/reviewers/{reviewer}?api-version={version}"
+func (c *Client) getReviewerURL(pullRequestID int, reviewerID string) string {
+	reviewerURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/reviewers/{reviewer}?api-version={version}"
 	r := strings.NewReplacer(
-		"{instance}", config.Instance,
-		"{project}", config.Project,
-		"{repository}", config.Repo,
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
 		"{pullRequest}", strconv.Itoa(pullRequestID),
-		"{reviewer}", config.UserID,
-		"{version}", "3.0-preview")
+		"{reviewer}", url.PathEscape(reviewerID),
+		"{version}", c.getAPIVersion(apiReviewers))
 
 	return r.Replace(reviewerURLTemplate)
 }
 
-func votePullRequest(pullRequestID int, vote int) error {
+func (c *Client) votePullRequest(pullRequestID int, vote int) error {
 	log.Printf("Vote on PR %v: %v...\n", pullRequestID, vote)
 
 	putVote := putVote{
 		Vote: vote,
 	}
 
-	url := getReviewerURL(pullRequestID)
+	url := c.getReviewerURL(pullRequestID, c.config.UserID)
 
-	err := putToVsts(url, putVote)
+	err := c.putToVsts(url, putVote)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) addReviewer(pullRequestID int, reviewerID string, isRequired bool) error {
+	log.Printf("Add reviewer %s to PR %v, required: %v...\n", reviewerID, pullRequestID, isRequired)
+
+	putReviewer := putReviewer{
+		IsRequired: isRequired,
+	}
+
+	url := c.getReviewerURL(pullRequestID, reviewerID)
+
+	err := c.putToVsts(url, putReviewer)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,1035 @@
+// Package vststest provides a fake Azure DevOps REST server for end-to-end tests of pull request reviews.
+package vststest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Thread statuses as sent in thread create and update requests
+var threadStatuses = map[int]string{
+	0: "unknown",
+	1: "active",
+	2: "fixed",
+	3: "wontFix",
+	4: "closed",
+	5: "byDesign",
+	6: "pending",
+}
+
+// Author is the identity of a comment author
+type Author struct {
+	ID string `json:"id"`
+}
+
+// Comment is a comment in a pull request thread
+type Comment struct {
+	ID              int    `json:"id"`
+	ParentCommentID int    `json:"parentCommentId"`
+	Author          Author `json:"author"`
+	Content         string `json:"content"`
+	CommentType     string `json:"commentType"`
+	IsDeleted       bool   `json:"isDeleted"`
+}
+
+// FilePosition is a position in a file
+type FilePosition struct {
+	Line   int `json:"line"`
+	Offset int `json:"offset"`
+}
+
+// ThreadContext is the file location a thread is attached to
+type ThreadContext struct {
+	FilePath       string        `json:"filePath"`
+	RightFileStart *FilePosition `json:"rightFileStart,omitempty"`
+	RightFileEnd   *FilePosition `json:"rightFileEnd,omitempty"`
+}
+
+// Thread is a pull request comment thread
+type Thread struct {
+	ID            int            `json:"id"`
+	Comments      []Comment      `json:"comments"`
+	Status        string         `json:"status"`
+	ThreadContext *ThreadContext `json:"threadContext,omitempty"`
+	Properties    Properties     `json:"properties,omitempty"`
+	IsDeleted     bool           `json:"isDeleted"`
+}
+
+// Property is a typed thread property
+type Property struct {
+	Type  string      `json:"$type"`
+	Value interface{} `json:"$value"`
+}
+
+// Properties are thread properties by name
+type Properties map[string]Property
+
+// set merges properties as sent in thread create and update requests
+func (p *Properties) set(properties map[string]postProperty) {
+	if len(properties) == 0 {
+		return
+	}
+	if *p == nil {
+		*p = make(Properties)
+	}
+	for name, property := range properties {
+		(*p)[name] = Property{Type: property.Type, Value: property.Value}
+	}
+}
+
+// FilePath returns the path of the file the thread is attached to, empty for general threads
+func (t Thread) FilePath() string {
+	if t.ThreadContext == nil {
+		return ""
+	}
+	return t.ThreadContext.FilePath
+}
+
+// StatusContext identifies a pull request status
+type StatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre"`
+}
+
+// Status is a pull request status posted by a service
+type Status struct {
+	ID          int           `json:"id"`
+	State       string        `json:"state"`
+	Description string        `json:"description"`
+	Context     StatusContext `json:"context"`
+	TargetURL   string        `json:"targetUrl"`
+}
+
+// Reviewer is a reviewer added to a pull request
+type Reviewer struct {
+	ID         string `json:"id"`
+	IsRequired bool   `json:"isRequired"`
+}
+
+// WorkItem is a work item of the project
+type WorkItem struct {
+	ID    int
+	Type  string
+	State string
+	Title string
+}
+
+// iteration is a push to a pull request with commits of source and target branch
+type iteration struct {
+	sourceCommit string
+	targetCommit string
+}
+
+// fork is the commit a branch was copied from
+type fork struct {
+	branch   string
+	commitID string
+}
+
+// Server is a fake Azure DevOps server hosting a single repository with in-memory state.
+// Collection of the client configuration must be empty.
+type Server struct {
+	*httptest.Server
+
+	// Project and Repo are the names the repository is served under
+	Project string
+	Repo    string
+	// UserID is the author of all comments created through the API
+	UserID string
+
+	mu            sync.Mutex
+	branches      map[string]map[string]string
+	commits       map[string]map[string]string
+	commitOwners  map[string]string
+	forks         map[string]fork
+	renames       map[string]map[string]string
+	threads       map[int][]*Thread
+	votes         map[int]map[string]int
+	reviewers     map[int]map[string]Reviewer
+	unknownIDs    map[string]bool
+	workItems     map[int]WorkItem
+	commitCounts  map[[2]string][2]int
+	links         map[int][]int
+	iterations    map[int][]iteration
+	itemRequests  map[string]int
+	statuses      map[int][]Status
+	healthHeaders http.Header
+	nextThreadID  int
+}
+
+// NewServer starts a fake server, the caller must Close it
+func NewServer(project string, repo string, userID string) *Server {
+	s := &Server{
+		Project:       project,
+		Repo:          repo,
+		UserID:        userID,
+		branches:      make(map[string]map[string]string),
+		commits:       make(map[string]map[string]string),
+		commitOwners:  make(map[string]string),
+		forks:         make(map[string]fork),
+		renames:       make(map[string]map[string]string),
+		threads:       make(map[int][]*Thread),
+		votes:         make(map[int]map[string]int),
+		reviewers:     make(map[int]map[string]Reviewer),
+		unknownIDs:    make(map[string]bool),
+		workItems:     make(map[int]WorkItem),
+		commitCounts:  make(map[[2]string][2]int),
+		links:         make(map[int][]int),
+		iterations:    make(map[int][]iteration),
+		itemRequests:  make(map[string]int),
+		statuses:      make(map[int][]Status),
+		healthHeaders: make(http.Header),
+		nextThreadID:  1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Host returns the address to use as instance in the client configuration
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// SetFile sets content of the file at path on branch, creating the branch if needed
+func (s *Server) SetFile(branch string, path string, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, ok := s.branches[branch]
+	if !ok {
+		files = make(map[string]string)
+		s.branches[branch] = files
+	}
+	files[path] = content
+}
+
+// DeleteFile removes a file from a branch
+func (s *Server) DeleteFile(branch string, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.branches[branch], path)
+}
+
+// RenameFile moves a file of branch from path from to path to, diffs report it as renamed
+func (s *Server) RenameFile(branch string, from string, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.branches[branch][to] = s.branches[branch][from]
+	delete(s.branches[branch], from)
+	if s.renames[branch] == nil {
+		s.renames[branch] = make(map[string]string)
+	}
+	s.renames[branch][to] = from
+}
+
+// CopyBranch creates branch to with all files of branch from.
+// Diffs between both branches are computed from this commit of branch from as merge base.
+func (s *Server) CopyBranch(from string, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.branches[to] = copyFiles(s.branches[from])
+	s.forks[to] = fork{branch: from, commitID: s.commit(from)}
+}
+
+// SetCommitCounts sets the number of commits target is ahead and behind of base, returned with diffs
+func (s *Server) SetCommitCounts(base string, target string, ahead int, behind int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commitCounts[[2]string{base, target}] = [2]int{ahead, behind}
+}
+
+// PushIteration adds an iteration to a pull request with the current files of source and target branch and returns its ID
+func (s *Server) PushIteration(pullRequestID int, source string, target string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it := iteration{
+		sourceCommit: s.commit(source),
+		targetCommit: s.commit(target),
+	}
+	s.iterations[pullRequestID] = append(s.iterations[pullRequestID], it)
+	return len(s.iterations[pullRequestID])
+}
+
+// Commit snapshots the current files of branch and returns the commit ID,
+// which stays readable as version of type commit when the branch changes
+func (s *Server) Commit(branch string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit(branch)
+}
+
+func (s *Server) commit(branch string) string {
+	files := copyFiles(s.branches[branch])
+	commitID := getCommitID(files)
+	s.commits[commitID] = files
+	s.commitOwners[commitID] = branch
+	return commitID
+}
+
+// getMergeBase returns the commit target was copied from base at, empty if target was not copied from base
+func (s *Server) getMergeBase(baseType string, base string, targetType string, target string) string {
+	if baseType == "commit" {
+		base = s.commitOwners[base]
+	}
+	if targetType == "commit" {
+		target = s.commitOwners[target]
+	}
+	if f, ok := s.forks[target]; ok && f.branch == base {
+		return f.commitID
+	}
+	return ""
+}
+
+// getVersion returns the files of a branch or commit
+func (s *Server) getVersion(versionType string, version string) (map[string]string, bool) {
+	if versionType == "commit" {
+		files, ok := s.commits[version]
+		return files, ok
+	}
+	files, ok := s.branches[version]
+	return files, ok
+}
+
+// ItemRequests returns how often the content of a file was requested on any branch
+func (s *Server) ItemRequests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.itemRequests[path]
+}
+
+// SetHealthHeader sets a header returned by the /healthcheck endpoint
+func (s *Server) SetHealthHeader(key string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.healthHeaders.Set(key, value)
+}
+
+// AddThread adds an existing thread to a pull request and returns its ID
+func (s *Server) AddThread(pullRequestID int, thread Thread) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	thread.ID = s.nextThreadID
+	s.nextThreadID++
+	s.threads[pullRequestID] = append(s.threads[pullRequestID], &thread)
+	return thread.ID
+}
+
+// SetThreadStatus sets the status of a thread as a user would, e.g. "closed"
+func (s *Server) SetThreadStatus(pullRequestID int, threadID int, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if thread := s.findThread(pullRequestID, threadID); thread != nil {
+		thread.Status = status
+	}
+}
+
+// Threads returns a copy of all threads of a pull request
+func (s *Server) Threads(pullRequestID int) []Thread {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var threads []Thread
+	for _, thread := range s.threads[pullRequestID] {
+		t := *thread
+		t.Comments = append([]Comment(nil), thread.Comments...)
+		threads = append(threads, t)
+	}
+	return threads
+}
+
+// Vote returns the vote of a reviewer, ok is false if the reviewer never voted
+func (s *Server) Vote(pullRequestID int, reviewerID string) (vote int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vote, ok = s.votes[pullRequestID][reviewerID]
+	return vote, ok
+}
+
+// Reviewers returns the reviewers of a pull request sorted by ID
+func (s *Server) Reviewers(pullRequestID int) []Reviewer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reviewers []Reviewer
+	for _, reviewer := range s.reviewers[pullRequestID] {
+		reviewers = append(reviewers, reviewer)
+	}
+	sort.Slice(reviewers, func(i, j int) bool {
+		return reviewers[i].ID < reviewers[j].ID
+	})
+	return reviewers
+}
+
+// SetUnknownIdentity makes adding the identity as reviewer fail
+func (s *Server) SetUnknownIdentity(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unknownIDs[id] = true
+}
+
+// SetWorkItem adds or replaces a work item
+func (s *Server) SetWorkItem(item WorkItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workItems[item.ID] = item
+}
+
+// LinkWorkItem links a work item to a pull request
+func (s *Server) LinkWorkItem(pullRequestID int, workItemID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.links[pullRequestID] = append(s.links[pullRequestID], workItemID)
+}
+
+// LinkedWorkItems returns the IDs of work items linked to a pull request
+func (s *Server) LinkedWorkItems(pullRequestID int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]int{}, s.links[pullRequestID]...)
+}
+
+// Status returns the latest status of a pull request with the given context name
+func (s *Server) Status(pullRequestID int, name string) (status Status, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, st := range s.statuses[pullRequestID] {
+		if st.Context.Name == name {
+			status, ok = st, true
+		}
+	}
+	return status, ok
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/healthcheck" {
+		s.mu.Lock()
+		for key, values := range s.healthHeaders {
+			w.Header()[key] = values
+		}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	witPrefix := "/" + s.Project + "/_apis/wit/workitems"
+	if strings.HasPrefix(r.URL.Path, witPrefix) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, witPrefix), "/")
+		switch {
+		case r.Method == "GET" && id == "":
+			s.getWorkItems(w, r)
+		case r.Method == "PATCH" && id != "":
+			s.patchWorkItem(w, r, atoi(id))
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	prefix := "/" + s.Project + "/_apis/git/repositories/" + s.Repo + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	switch {
+	case r.Method == "GET" && match(segments, "diffs", "commits"):
+		s.getDiffs(w, r)
+	case r.Method == "GET" && match(segments, "items"):
+		s.getItem(w, r)
+	case match(segments, "pullRequests", "*", "threads"):
+		s.handleThreads(w, r, atoi(segments[1]))
+	case match(segments, "pullRequests", "*", "threads", "*"):
+		s.handleThread(w, r, atoi(segments[1]), atoi(segments[3]))
+	case match(segments, "pullRequests", "*", "threads", "*", "comments"):
+		s.handleComments(w, r, atoi(segments[1]), atoi(segments[3]))
+	case r.Method == "PATCH" && match(segments, "pullRequests", "*", "threads", "*", "comments", "*"):
+		s.patchComment(w, r, atoi(segments[1]), atoi(segments[3]), atoi(segments[5]))
+	case r.Method == "GET" && match(segments, "pullRequests", "*", "iterations"):
+		s.getIterations(w, r, atoi(segments[1]))
+	case r.Method == "GET" && match(segments, "pullRequests", "*", "iterations", "*", "changes"):
+		s.getIterationChanges(w, r, atoi(segments[1]), atoi(segments[3]))
+	case r.Method == "POST" && match(segments, "pullRequests", "*", "statuses"):
+		s.postStatus(w, r, atoi(segments[1]))
+	case r.Method == "GET" && match(segments, "pullRequests", "*", "workitems"):
+		s.getPullRequestWorkItems(w, r, atoi(segments[1]))
+	case r.Method == "PUT" && match(segments, "pullRequests", "*", "reviewers", "*"):
+		s.putReviewer(w, r, atoi(segments[1]), segments[3])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) getDiffs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	base, ok := s.getVersion(query.Get("baseVersionType"), query.Get("baseVersion"))
+	if !ok {
+		http.Error(w, "base version not found", http.StatusNotFound)
+		return
+	}
+	target, ok := s.getVersion(query.Get("targetVersionType"), query.Get("targetVersion"))
+	if !ok {
+		http.Error(w, "target version not found", http.StatusNotFound)
+		return
+	}
+
+	// like VSTS, changes are computed from the merge base
+	commonCommit := s.getMergeBase(query.Get("baseVersionType"), query.Get("baseVersion"), query.Get("targetVersionType"), query.Get("targetVersion"))
+	if len(commonCommit) > 0 {
+		base = s.commits[commonCommit]
+	}
+
+	// pages with $top and $skip
+	targetBranch := query.Get("targetVersion")
+	if query.Get("targetVersionType") == "commit" {
+		targetBranch = s.commitOwners[targetBranch]
+	}
+	all := getChanges(base, target, s.renames[targetBranch])
+	skip := atoi(query.Get("$skip"))
+	if skip > len(all) {
+		skip = len(all)
+	}
+	changes := all[skip:]
+	if top := atoi(query.Get("$top")); top > 0 && top < len(changes) {
+		changes = changes[:top]
+	}
+	allChangesIncluded := skip+len(changes) == len(all)
+
+	counts := s.commitCounts[[2]string{query.Get("baseVersion"), query.Get("targetVersion")}]
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"allChangesIncluded": allChangesIncluded,
+		"changes":            changes,
+		"commonCommit":       commonCommit,
+		"aheadCount":         counts[0],
+		"behindCount":        counts[1],
+	})
+}
+
+func (s *Server) getItem(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.itemRequests[query.Get("scopePath")]++
+	files, ok := s.getVersion(query.Get("versionType"), query.Get("version"))
+	if !ok {
+		http.Error(w, "version not found", http.StatusNotFound)
+		return
+	}
+	content, ok := files[query.Get("scopePath")]
+	if !ok {
+		http.Error(w, "item not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write([]byte(content))
+}
+
+func (s *Server) getIterations(w http.ResponseWriter, r *http.Request, pullRequestID int) {
+	type commitRef struct {
+		CommitID string `json:"commitId"`
+	}
+	type iterationRef struct {
+		ID              int       `json:"id"`
+		SourceRefCommit commitRef `json:"sourceRefCommit"`
+		TargetRefCommit commitRef `json:"targetRefCommit"`
+	}
+
+	iterations := []iterationRef{}
+	for i, it := range s.iterations[pullRequestID] {
+		iterations = append(iterations, iterationRef{
+			ID:              i + 1,
+			SourceRefCommit: commitRef{it.sourceCommit},
+			TargetRefCommit: commitRef{it.targetCommit},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"value": iterations,
+		"count": len(iterations),
+	})
+}
+
+// getIterationChanges compares the source of an iteration with the source of iteration $compareTo,
+// or with its target if $compareTo is 0, and pages with $top and $skip
+func (s *Server) getIterationChanges(w http.ResponseWriter, r *http.Request, pullRequestID int, iterationID int) {
+	iterations := s.iterations[pullRequestID]
+	query := r.URL.Query()
+	compareTo := atoi(query.Get("$compareTo"))
+	if iterationID < 1 || iterationID > len(iterations) || compareTo < 0 || compareTo > len(iterations) {
+		http.Error(w, "iteration not found", http.StatusNotFound)
+		return
+	}
+
+	it := iterations[iterationID-1]
+	base := s.commits[it.targetCommit]
+	if compareTo > 0 {
+		base = s.commits[iterations[compareTo-1].sourceCommit]
+	}
+
+	type changeEntry struct {
+		ChangeTrackingID int    `json:"changeTrackingId"`
+		Item             item   `json:"item"`
+		ChangeType       string `json:"changeType"`
+		OriginalPath     string `json:"originalPath,omitempty"`
+	}
+
+	entries := []changeEntry{}
+	for i, c := range getChanges(base, s.commits[it.sourceCommit], s.renames[s.commitOwners[it.sourceCommit]]) {
+		entries = append(entries, changeEntry{i + 1, c.Item, c.ChangeType, c.OriginalPath})
+	}
+
+	skip := atoi(query.Get("$skip"))
+	if skip > len(entries) {
+		skip = len(entries)
+	}
+	entries = entries[skip:]
+
+	response := map[string]interface{}{}
+	if top := atoi(query.Get("$top")); top > 0 && top < len(entries) {
+		entries = entries[:top]
+		response["nextSkip"] = skip + top
+		response["nextTop"] = top
+	}
+	response["changeEntries"] = entries
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+type postProperty struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type postComment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     int    `json:"commentType"`
+}
+
+func (s *Server) handleThreads(w http.ResponseWriter, r *http.Request, pullRequestID int) {
+	switch r.Method {
+	case "GET":
+		threads := []*Thread{}
+		threads = append(threads, s.threads[pullRequestID]...)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"value": threads,
+			"count": len(threads),
+		})
+	case "POST":
+		var body struct {
+			Comments      []postComment           `json:"comments"`
+			Properties    map[string]postProperty `json:"properties"`
+			Status        int                     `json:"status"`
+			ThreadContext *ThreadContext          `json:"threadContext"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		thread := &Thread{
+			ID:     s.nextThreadID,
+			Status: threadStatuses[body.Status],
+		}
+		s.nextThreadID++
+		if body.ThreadContext != nil && body.ThreadContext.FilePath != "" {
+			thread.ThreadContext = body.ThreadContext
+		}
+		for _, c := range body.Comments {
+			s.appendComment(thread, c)
+		}
+		thread.Properties.set(body.Properties)
+
+		s.threads[pullRequestID] = append(s.threads[pullRequestID], thread)
+		writeJSON(w, http.StatusOK, thread)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleThread(w http.ResponseWriter, r *http.Request, pullRequestID int, threadID int) {
+	thread := s.findThread(pullRequestID, threadID)
+	if thread == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, thread)
+	case "PATCH":
+		var body struct {
+			Status     *int                    `json:"status"`
+			Properties map[string]postProperty `json:"properties"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if body.Status != nil {
+			thread.Status = threadStatuses[*body.Status]
+		}
+		thread.Properties.set(body.Properties)
+		writeJSON(w, http.StatusOK, thread)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleComments(w http.ResponseWriter, r *http.Request, pullRequestID int, threadID int) {
+	thread := s.findThread(pullRequestID, threadID)
+	if thread == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"value": thread.Comments,
+			"count": len(thread.Comments),
+		})
+	case "POST":
+		var body postComment
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		comment := s.appendComment(thread, body)
+		writeJSON(w, http.StatusOK, comment)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) patchComment(w http.ResponseWriter, r *http.Request, pullRequestID int, threadID int, commentID int) {
+	thread := s.findThread(pullRequestID, threadID)
+	if thread == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for i := range thread.Comments {
+		if thread.Comments[i].ID == commentID {
+			thread.Comments[i].Content = body.Content
+			writeJSON(w, http.StatusOK, thread.Comments[i])
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) putReviewer(w http.ResponseWriter, r *http.Request, pullRequestID int, reviewerID string) {
+	var body struct {
+		Vote       *int `json:"vote"`
+		IsRequired bool `json:"isRequired"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.unknownIDs[reviewerID] {
+		http.Error(w, "identity not found", http.StatusBadRequest)
+		return
+	}
+
+	reviewers, ok := s.reviewers[pullRequestID]
+	if !ok {
+		reviewers = make(map[string]Reviewer)
+		s.reviewers[pullRequestID] = reviewers
+	}
+	reviewers[reviewerID] = Reviewer{ID: reviewerID, IsRequired: body.IsRequired}
+
+	// adding a reviewer does not vote
+	vote := 0
+	if body.Vote != nil {
+		votes, ok := s.votes[pullRequestID]
+		if !ok {
+			votes = make(map[string]int)
+			s.votes[pullRequestID] = votes
+		}
+		vote = *body.Vote
+		votes[reviewerID] = vote
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":         reviewerID,
+		"vote":       vote,
+		"isRequired": body.IsRequired,
+	})
+}
+
+func (s *Server) getPullRequestWorkItems(w http.ResponseWriter, r *http.Request, pullRequestID int) {
+	type resourceRef struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+
+	refs := []resourceRef{}
+	for _, id := range s.links[pullRequestID] {
+		refs = append(refs, resourceRef{ID: strconv.Itoa(id), URL: s.URL + "/_apis/wit/workItems/" + strconv.Itoa(id)})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"count": len(refs),
+		"value": refs,
+	})
+}
+
+// getWorkItems returns null for missing work items as with errorPolicy=omit
+func (s *Server) getWorkItems(w http.ResponseWriter, r *http.Request) {
+	items := []interface{}{}
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		item, ok := s.workItems[atoi(id)]
+		if !ok {
+			items = append(items, nil)
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"id": item.ID,
+			"fields": map[string]string{
+				"System.WorkItemType": item.Type,
+				"System.State":        item.State,
+				"System.Title":        item.Title,
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"count": len(items),
+		"value": items,
+	})
+}
+
+// patchWorkItem supports adding pull request artifact links
+func (s *Server) patchWorkItem(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Header.Get("Content-Type") != "application/json-patch+json" {
+		http.Error(w, "content type must be application/json-patch+json", http.StatusUnsupportedMediaType)
+		return
+	}
+	if _, ok := s.workItems[id]; !ok {
+		http.Error(w, "work item not found", http.StatusNotFound)
+		return
+	}
+
+	var patch []struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value struct {
+			Rel string `json:"rel"`
+			URL string `json:"url"`
+		} `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, operation := range patch {
+		artifactPrefix := "vstfs:///Git/PullRequestId/"
+		if operation.Op != "add" || operation.Path != "/relations/-" || operation.Value.Rel != "ArtifactLink" || !strings.HasPrefix(operation.Value.URL, artifactPrefix) {
+			http.Error(w, "unsupported patch operation", http.StatusBadRequest)
+			return
+		}
+		artifact := strings.Split(strings.TrimPrefix(operation.Value.URL, artifactPrefix), "%2F")
+		pullRequestID := atoi(artifact[len(artifact)-1])
+		s.links[pullRequestID] = append(s.links[pullRequestID], id)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id})
+}
+
+func (s *Server) postStatus(w http.ResponseWriter, r *http.Request, pullRequestID int) {
+	var status Status
+	if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status.ID = len(s.statuses[pullRequestID]) + 1
+	s.statuses[pullRequestID] = append(s.statuses[pullRequestID], status)
+	writeJSON(w, http.StatusOK, status)
+}
+
+type item struct {
+	ObjectID         string `json:"objectId,omitempty"`
+	OriginalObjectID string `json:"originalObjectId,omitempty"`
+	Path             string `json:"path"`
+	IsFolder         bool   `json:"isFolder"`
+}
+
+type change struct {
+	Item         item   `json:"item"`
+	ChangeType   string `json:"changeType"`
+	OriginalPath string `json:"originalPath,omitempty"`
+}
+
+// getChanges returns the changed files from base to target sorted by path,
+// renames maps paths of renamed files in target to their original paths
+func getChanges(base map[string]string, target map[string]string, renames map[string]string) []change {
+	renamed := make(map[string]string)
+	for path, originalPath := range renames {
+		_, inBase := base[path]
+		_, originalInBase := base[originalPath]
+		_, originalInTarget := target[originalPath]
+		if _, inTarget := target[path]; inTarget && !inBase && originalInBase && !originalInTarget {
+			renamed[path] = originalPath
+			renamed[originalPath] = ""
+		}
+	}
+
+	var paths []string
+	for path := range base {
+		paths = append(paths, path)
+	}
+	for path := range target {
+		if _, ok := base[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []change{}
+	for _, path := range paths {
+		baseContent, inBase := base[path]
+		targetContent, inTarget := target[path]
+		originalPath, isRenamed := renamed[path]
+		switch {
+		case isRenamed && len(originalPath) == 0:
+			// the original path of a renamed file is part of its rename
+		case isRenamed:
+			changeType := "rename"
+			if base[originalPath] != targetContent {
+				changeType = "edit, rename"
+			}
+			changes = append(changes, change{Item: item{ObjectID: getObjectID(targetContent), OriginalObjectID: getObjectID(base[originalPath]), Path: path}, ChangeType: changeType, OriginalPath: originalPath})
+		case !inBase:
+			changes = append(changes, change{Item: item{ObjectID: getObjectID(targetContent), Path: path}, ChangeType: "add"})
+		case !inTarget:
+			changes = append(changes, change{Item: item{OriginalObjectID: getObjectID(baseContent), Path: path}, ChangeType: "delete"})
+		case baseContent != targetContent:
+			changes = append(changes, change{Item: item{ObjectID: getObjectID(targetContent), OriginalObjectID: getObjectID(baseContent), Path: path}, ChangeType: "edit"})
+		}
+	}
+	return changes
+}
+
+func copyFiles(files map[string]string) map[string]string {
+	copied := make(map[string]string)
+	for path, content := range files {
+		copied[path] = content
+	}
+	return copied
+}
+
+// getObjectID returns a blob ID derived from the content of a file
+func getObjectID(content string) string {
+	hash := sha1.Sum([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// getCommitID returns a commit ID derived from the files of a snapshot
+func getCommitID(files map[string]string) string {
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	hash := sha1.New()
+	for _, path := range paths {
+		fmt.Fprintf(hash, "%s\x00%s\x00", path, files[path])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (s *Server) appendComment(thread *Thread, c postComment) Comment {
+	commentType := "text"
+	if c.CommentType == 2 {
+		commentType = "codeChange"
+	} else if c.CommentType == 3 {
+		commentType = "system"
+	}
+
+	comment := Comment{
+		ID:              len(thread.Comments) + 1,
+		ParentCommentID: c.ParentCommentID,
+		Author:          Author{ID: s.UserID},
+		Content:         c.Content,
+		CommentType:     commentType,
+	}
+	thread.Comments = append(thread.Comments, comment)
+	return comment
+}
+
+func (s *Server) findThread(pullRequestID int, threadID int) *Thread {
+	for _, thread := range s.threads[pullRequestID] {
+		if thread.ID == threadID {
+			return thread
+		}
+	}
+	return nil
+}
+
+// match reports whether path segments equal pattern, "*" matches any single segment
+func match(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && !strings.EqualFold(p, segments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,147 @@
+package vsts
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// maxWebhookBodySize limits the size of service hook payloads
+const maxWebhookBodySize = 4 << 20
+
+var webhookEventTypes = []string{
+	"git.pullrequest.created",
+	"git.pullrequest.updated",
+}
+
+// WebhookHandler accepts pull request service hook events and reviews them in-process
+type WebhookHandler struct {
+	client *Client
+
+	// reviews are serialized, one pull request at a time
+	reviewMu sync.Mutex
+	inFlight sync.WaitGroup
+
+	// pending is the latest event of each pull request waiting for its review,
+	// so events arriving during a review replace each other instead of running out of order
+	pendingMu sync.Mutex
+	pending   map[int]*PullRequest
+}
+
+// NewWebhookHandler creates a handler for Azure DevOps service hook POSTs
+func NewWebhookHandler(client *Client) *WebhookHandler {
+	return &WebhookHandler{client: client, pending: make(map[int]*PullRequest)}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="vsts-pr"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	pr, err := ReadPullRequest(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		log.Printf("failed to parse service hook payload: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !isWebhookEventType(pr.EventType) {
+		log.Printf("ignore event %s of type %s\n", pr.ID, pr.EventType)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	log.Printf("Got PR update: %v\n", pr.Resource.PullRequestID)
+
+	if !pr.IsTargetBranch(h.client.config.MasterBranch) {
+		log.Printf("unexpected target branch: %s\n", pr.Resource.TargetRefName)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.enqueue(pr)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// enqueue schedules the review of an event, replacing a pending event of the same pull request
+func (h *WebhookHandler) enqueue(pr *PullRequest) {
+	pullRequestID := pr.Resource.PullRequestID
+
+	h.pendingMu.Lock()
+	_, waiting := h.pending[pullRequestID]
+	h.pending[pullRequestID] = pr
+	h.pendingMu.Unlock()
+
+	if waiting {
+		log.Printf("event %s replaces the pending event of PR %v\n", pr.ID, pullRequestID)
+		return
+	}
+
+	h.inFlight.Add(1)
+	go func() {
+		defer h.inFlight.Done()
+
+		h.reviewMu.Lock()
+		defer h.reviewMu.Unlock()
+
+		h.pendingMu.Lock()
+		pr := h.pending[pullRequestID]
+		delete(h.pending, pullRequestID)
+		h.pendingMu.Unlock()
+
+		h.review(pr)
+	}()
+}
+
+// review reviews an event, a panic only fails this review and not the server
+func (h *WebhookHandler) review(pr *PullRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("review of PR %v panicked: %v\n%s\n", pr.Resource.PullRequestID, r, debug.Stack())
+		}
+	}()
+
+	if err := h.client.Review(pr); err != nil {
+		log.Printf("review of PR %v failed: %v\n", pr.Resource.PullRequestID, err)
+	}
+}
+
+// Wait blocks until all accepted reviews are completed
+func (h *WebhookHandler) Wait() {
+	h.inFlight.Wait()
+}
+
+func (h *WebhookHandler) authorized(r *http.Request) bool {
+	if h.client.config.WebhookUsername == "" && h.client.config.WebhookPassword == "" {
+		return true
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(username), []byte(h.client.config.WebhookUsername)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(h.client.config.WebhookPassword)) == 1
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, t := range webhookEventTypes {
+		if strings.EqualFold(t, eventType) {
+			return true
+		}
+	}
+
+	return false
+}
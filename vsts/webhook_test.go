@@ -0,0 +1,167 @@
+package vsts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestWebhookRequest(t *testing.T, eventType string) *http.Request {
+	pr := newTestPullRequest()
+	pr.EventType = eventType
+	body, err := json.Marshal(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+}
+
+func TestWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    func(t *testing.T) *http.Request
+		wantStatus int
+		wantReview bool
+	}{
+		{
+			name: "accepted",
+			request: func(t *testing.T) *http.Request {
+				r := newTestWebhookRequest(t, "git.pullrequest.updated")
+				r.SetBasicAuth("hook", "secret")
+				return r
+			},
+			wantStatus: http.StatusAccepted,
+			wantReview: true,
+		},
+		{
+			name: "wrong method",
+			request: func(t *testing.T) *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.SetBasicAuth("hook", "secret")
+				return r
+			},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name: "no credentials",
+			request: func(t *testing.T) *http.Request {
+				return newTestWebhookRequest(t, "git.pullrequest.updated")
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong password",
+			request: func(t *testing.T) *http.Request {
+				r := newTestWebhookRequest(t, "git.pullrequest.updated")
+				r.SetBasicAuth("hook", "guess")
+				return r
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "other event type",
+			request: func(t *testing.T) *http.Request {
+				r := newTestWebhookRequest(t, "git.push")
+				r.SetBasicAuth("hook", "secret")
+				return r
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "missing source ref",
+			request: func(t *testing.T) *http.Request {
+				pr := newTestPullRequest()
+				pr.Resource.SourceRefName = "refs"
+				body, err := json.Marshal(pr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+				r.SetBasicAuth("hook", "secret")
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "body too large",
+			request: func(t *testing.T) *http.Request {
+				body := `{"id": "event-id", "message": "` + strings.Repeat("x", maxWebhookBodySize) + `"}`
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+				r.SetBasicAuth("hook", "secret")
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, client := newTestClient(t)
+			client.config.WebhookUsername = "hook"
+			client.config.WebhookPassword = "secret"
+			client.config.ChangeGroups = []changeGroup{{Files: []string{"/a.txt", "/b.txt"}}}
+
+			server.SetFile("master", "/a.txt", "a")
+			server.SetFile("master", "/b.txt", "b")
+			server.CopyBranch("master", testSourceBranch)
+			server.SetFile(testSourceBranch, "/a.txt", "changed")
+
+			handler := NewWebhookHandler(client)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, test.request(t))
+			handler.Wait()
+
+			if recorder.Code != test.wantStatus {
+				t.Errorf("status %d, want %d", recorder.Code, test.wantStatus)
+			}
+			if reviewed := len(server.Threads(testPullRequestID)) > 0; reviewed != test.wantReview {
+				t.Errorf("reviewed %v, want %v", reviewed, test.wantReview)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerLatestEvent(t *testing.T) {
+	server, client := newTestClient(t)
+	client.config.ChangeGroups = []changeGroup{{Files: []string{"/a.txt", "/b.txt"}}}
+
+	server.SetFile("master", "/a.txt", "a")
+	server.SetFile("master", "/b.txt", "b")
+	server.CopyBranch("master", testSourceBranch)
+	server.SetFile(testSourceBranch, "/a.txt", "changed")
+	incomplete := server.Commit(testSourceBranch)
+	server.SetFile(testSourceBranch, "/b.txt", "changed")
+	complete := server.Commit(testSourceBranch)
+
+	handler := NewWebhookHandler(client)
+
+	// both events arrive while another review runs, only the latest one is reviewed
+	handler.reviewMu.Lock()
+	for _, commitID := range []string{incomplete, complete} {
+		r := newTestWebhookRequest(t, "git.pullrequest.updated")
+		pr, err := ReadPullRequest(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pr.Resource.LastMergeSourceCommit.CommitID = commitID
+		handler.enqueue(pr)
+	}
+	handler.reviewMu.Unlock()
+	handler.Wait()
+
+	if threads := server.Threads(testPullRequestID); len(threads) > 0 {
+		t.Errorf("the outdated event was reviewed: %+v", threads)
+	}
+}
+
+func TestWebhookHandlerRecovers(t *testing.T) {
+	_, client := newTestClient(t)
+	pr := newTestPullRequest()
+	pr.Resource.SourceRefName = "refs"
+
+	// a panicking review is logged instead of stopping the server
+	NewWebhookHandler(client).review(pr)
+}
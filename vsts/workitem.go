@@ -0,0 +1,117 @@
+package vsts
+
+import (
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func (c *Client) getPullRequestWorkItemsURL(pullRequestID int) string {
+	workItemsURLTemplate := "{collectionURL}/{project}/_apis/git/repositories/{repository}/pullRequests/{pullRequest}/workitems?api-version={version}"
+	r := strings.NewReplacer(
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{repository}", url.PathEscape(c.config.Repo),
+		"{pullRequest}", strconv.Itoa(pullRequestID),
+		"{version}", c.getAPIVersion(apiPullRequestWorkItems))
+
+	return r.Replace(workItemsURLTemplate)
+}
+
+func (c *Client) getWorkItemsURL(ids []int) string {
+	idStrings := make([]string, 0, len(ids))
+	for _, id := range ids {
+		idStrings = append(idStrings, strconv.Itoa(id))
+	}
+
+	workItemsURLTemplate := "{collectionURL}/{project}/_apis/wit/workitems?ids={ids}&fields=System.WorkItemType,System.State,System.Title&errorPolicy=omit&api-version={version}"
+	r := strings.NewReplacer(
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{ids}", strings.Join(idStrings, ","),
+		"{version}", c.getAPIVersion(apiWorkItems))
+
+	return r.Replace(workItemsURLTemplate)
+}
+
+func (c *Client) getWorkItemURL(id int) string {
+	workItemURLTemplate := "{collectionURL}/{project}/_apis/wit/workitems/{id}?api-version={version}"
+	r := strings.NewReplacer(
+		"{collectionURL}", c.getCollectionURL(),
+		"{project}", url.PathEscape(c.config.Project),
+		"{id}", strconv.Itoa(id),
+		"{version}", c.getAPIVersion(apiWorkItems))
+
+	return r.Replace(workItemURLTemplate)
+}
+
+// getPullRequestWorkItems returns the IDs of the work items linked to a pull request
+func (c *Client) getPullRequestWorkItems(pullRequestID int) ([]int, error) {
+	refs := resourceRefs{}
+	err := c.getFromVsts(c.getPullRequestWorkItemsURL(pullRequestID), &refs)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, ref := range refs.Value {
+		id, err := strconv.Atoi(ref.ID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// getWorkItems returns type, state and title of work items, missing work items are left out
+func (c *Client) getWorkItems(ids []int) ([]workItem, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	items := workItems{}
+	err := c.getFromVsts(c.getWorkItemsURL(ids), &items)
+	if err != nil {
+		return nil, err
+	}
+
+	// missing work items are null with errorPolicy=omit
+	var found []workItem
+	for _, item := range items.Value {
+		if item.ID != 0 {
+			found = append(found, item)
+		}
+	}
+
+	return found, nil
+}
+
+// getPullRequestArtifactURL returns the artifact link of a pull request used in work item relations
+func getPullRequestArtifactURL(pr *PullRequest) string {
+	return "vstfs:///Git/PullRequestId/" + url.PathEscape(pr.Resource.Repository.Project.ID+"/"+pr.Resource.Repository.ID+"/"+strconv.Itoa(pr.Resource.PullRequestID))
+}
+
+// linkWorkItem links a work item to a pull request
+func (c *Client) linkWorkItem(pr *PullRequest, id int) error {
+	log.Printf("Link work item %d to PR %v...\n", id, pr.Resource.PullRequestID)
+
+	patch := jsonPatch{{
+		Op:   "add",
+		Path: "/relations/-",
+		Value: workItemRelation{
+			Rel:        "ArtifactLink",
+			URL:        getPullRequestArtifactURL(pr),
+			Attributes: map[string]string{"name": "Pull Request"},
+		},
+	}}
+
+	err := c.patchToVsts(c.getWorkItemURL(id), patch)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}